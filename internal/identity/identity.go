@@ -0,0 +1,49 @@
+// Package identity builds the OpenTelemetry resource that identifies the service, shared by the traces, logs and
+// metrics packages so the signals of a service can be correlated.
+package identity
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Service is the identity of the service, as passed to the Initialize funcs.
+type Service struct {
+	Name       string
+	Version    string
+	BuildDate  string
+	CommitHash string
+	Env        string
+}
+
+// Attributes returns the resource attributes of the service. Empty values are left out.
+func (s Service) Attributes() []attribute.KeyValue {
+	identity := []struct {
+		key   attribute.Key
+		value string
+	}{
+		{semconv.ServiceNameKey, s.Name},
+		{semconv.ServiceVersionKey, s.Version},
+		{"buildDate", s.BuildDate},
+		{"commitHash", s.CommitHash},
+		{"env", s.Env},
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(identity))
+	for _, id := range identity {
+		if len(id.value) > 0 {
+			attrs = append(attrs, id.key.String(id.value))
+		}
+	}
+	return attrs
+}
+
+// Resource creates the resource of the service, with the attributes found by the detectors and the extra attributes.
+func (s Service) Resource(detectors []resource.Detector, attrs ...attribute.KeyValue) (*resource.Resource, error) {
+	return resource.New(context.Background(),
+		resource.WithDetectors(detectors...),
+		resource.WithAttributes(append(s.Attributes(), attrs...)...))
+}
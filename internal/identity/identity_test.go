@@ -0,0 +1,27 @@
+package identity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/internal/identity"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestService_Resource(t *testing.T) {
+	svc := identity.Service{Name: "api", Version: "1.0.0", Env: "prod"}
+	res, err := svc.Resource(nil, attribute.String("team", "core"))
+	require.NoError(t, err)
+
+	attrs := make(map[string]string)
+	for _, kv := range res.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	assert.Equal(t, map[string]string{
+		"service.name":    "api",
+		"service.version": "1.0.0",
+		"env":             "prod",
+		"team":            "core",
+	}, attrs, "empty values are left out")
+}
@@ -0,0 +1,138 @@
+// Package redact removes sensitive values from the URLs written to logs and traces.
+package redact
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces the values that are redacted.
+const Mask = "****"
+
+// AllPathParams, in QueryPolicy.PathParams, redacts every path parameter.
+const AllPathParams = "*"
+
+// DefaultQueryParams are the query parameters masked by DefaultQueryPolicy.
+var DefaultQueryParams = []string{
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"password",
+	"passwd",
+	"pwd",
+	"secret",
+	"client_secret",
+	"api_key",
+	"apikey",
+	"signature",
+	"sig",
+	"code",
+	"x-amz-signature",
+	"x-amz-credential",
+	"x-amz-security-token",
+	"x-goog-signature",
+	"x-goog-credential",
+}
+
+// defaultQueryPattern masks the parameters whose names suggest a credential, e.g., "session_token" or "db_password".
+var defaultQueryPattern = regexp.MustCompile(`(?i)(token|secret|passw|signature|credential)`)
+
+// QueryPolicy controls how the query string and the path of a request URL are written.
+// Parameter names are case-insensitive.
+type QueryPolicy struct {
+	// Drop lists the query parameters that are removed.
+	Drop []string
+	// Mask lists the query parameters whose values are replaced with ****.
+	Mask []string
+	// MaskPatterns masks the query parameters whose names match any of the patterns.
+	MaskPatterns []*regexp.Regexp
+	// PathParams lists the gin path parameters whose values are replaced with **** in the path,
+	// e.g., /users/:email. Use AllPathParams to redact all of them.
+	PathParams []string
+}
+
+// DefaultQueryPolicy returns the policy used when none is supplied: the DefaultQueryParams, and the parameters
+// whose names contain token, secret, passw, signature or credential, are masked. Path parameters are not redacted.
+func DefaultQueryPolicy() QueryPolicy {
+	return QueryPolicy{
+		Mask:         append([]string(nil), DefaultQueryParams...),
+		MaskPatterns: []*regexp.Regexp{defaultQueryPattern},
+	}
+}
+
+// Query returns the raw query with the parameters dropped or masked. The other parameters are left as they are,
+// in the same order.
+func (p QueryPolicy) Query(rawQuery string) string {
+	if len(rawQuery) == 0 {
+		return rawQuery
+	}
+
+	drop, mask := nameSet(p.Drop), nameSet(p.Mask)
+	parts := strings.Split(rawQuery, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		rawName, _, _ := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		name = strings.ToLower(name)
+
+		switch {
+		case drop[name]:
+			continue
+		case mask[name] || p.matches(name):
+			kept = append(kept, rawName+"="+Mask)
+		default:
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// Path returns the path with the values of the path parameters listed in PathParams masked.
+// route is the gin route template the path matched, e.g., /users/:email; if it is empty, the path is returned as is.
+func (p QueryPolicy) Path(path, route string) string {
+	if len(p.PathParams) == 0 || len(route) == 0 {
+		return path
+	}
+
+	params := nameSet(p.PathParams)
+	all := params[AllPathParams]
+	segments := strings.Split(path, "/")
+	for i, rs := range strings.Split(route, "/") {
+		if i >= len(segments) || len(rs) < 2 {
+			continue
+		}
+		switch rs[0] {
+		case ':':
+			if all || params[strings.ToLower(rs[1:])] {
+				segments[i] = Mask
+			}
+		case '*':
+			if all || params[strings.ToLower(rs[1:])] {
+				segments = append(segments[:i], Mask)
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (p QueryPolicy) matches(name string) bool {
+	for _, re := range p.MaskPatterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func nameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[strings.ToLower(n)] = true
+	}
+	return set
+}
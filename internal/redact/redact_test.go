@@ -0,0 +1,45 @@
+package redact_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twistingmercury/monitoring/internal/redact"
+)
+
+func TestQueryPolicy_Query(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   redact.QueryPolicy
+		query    string
+		expected string
+	}{
+		{"empty", redact.DefaultQueryPolicy(), "", ""},
+		{"untouched", redact.DefaultQueryPolicy(), "q=shoes&size=9.0&sort=asc", "q=shoes&size=9.0&sort=asc"},
+		{"defaults", redact.DefaultQueryPolicy(), "q=shoes&TOKEN=abc&code=xyz&X-Amz-Signature=f00", "q=shoes&TOKEN=****&code=****&X-Amz-Signature=****"},
+		{"default pattern", redact.DefaultQueryPolicy(), "session_token=abc&db_password=hunter2&page=2", "session_token=****&db_password=****&page=2"},
+		{"escaped name", redact.DefaultQueryPolicy(), "pass%77ord=hunter2", "pass%77ord=****"},
+		{"no value", redact.DefaultQueryPolicy(), "token&debug", "token=****&debug"},
+		{"drop", redact.QueryPolicy{Drop: []string{"email"}}, "email=jane%40example.com&page=2", "page=2"},
+		{"mask pattern", redact.QueryPolicy{MaskPatterns: []*regexp.Regexp{regexp.MustCompile(`^utm_`)}}, "utm_source=mail&id=1", "utm_source=****&id=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Query(tt.query))
+		})
+	}
+}
+
+func TestQueryPolicy_Path(t *testing.T) {
+	p := redact.QueryPolicy{PathParams: []string{"email"}}
+	assert.Equal(t, "/users/****/orders/42", p.Path("/users/jane@example.com/orders/42", "/users/:email/orders/:id"))
+	assert.Equal(t, "/users/jane@example.com", p.Path("/users/jane@example.com", ""))
+
+	all := redact.QueryPolicy{PathParams: []string{redact.AllPathParams}}
+	assert.Equal(t, "/users/****/orders/****", all.Path("/users/jane@example.com/orders/42", "/users/:email/orders/:id"))
+	assert.Equal(t, "/files/****", all.Path("/files/a/b/c.txt", "/files/*path"))
+
+	assert.Equal(t, "/users/42", redact.DefaultQueryPolicy().Path("/users/42", "/users/:id"))
+}
@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// AccessLogPolicy controls which successful requests the logging middleware logs. Failed requests, i.e., the
+// status is 500 or above, or errors were added to the gin context, are always logged, as are slow requests.
+type AccessLogPolicy struct {
+	// SkipPaths lists the paths, or gin route templates, of the requests that are not logged, e.g., /health or
+	// /metrics. Wildcards are supported, e.g., /debug/*.
+	SkipPaths []string
+	// SampleEvery logs 1 in SampleEvery successful requests, per route. Zero or one logs them all.
+	SampleEvery uint32
+	// Burst, if not zero, logs at most Burst successful requests per BurstPeriod, per route. Once the burst is
+	// exhausted, SampleEvery applies, or the requests are not logged if it is not set.
+	Burst uint32
+	// BurstPeriod is the period of Burst. The default is one second.
+	BurstPeriod time.Duration
+	// SlowThreshold, if not zero, logs the requests that take longer, even if they would be skipped or sampled out.
+	SlowThreshold time.Duration
+}
+
+// AccessLogStats reports the number of successful requests the access log policies did not log.
+type AccessLogStats struct {
+	// Skipped is the number of requests not logged because of SkipPaths.
+	Skipped uint64
+	// Sampled is the number of requests not logged because of SampleEvery or Burst.
+	Sampled uint64
+}
+
+var accessSkipped, accessSampled atomic.Uint64
+
+// DroppedAccessLogs returns the number of successful requests not logged since the logging system was initialized.
+func DroppedAccessLogs() AccessLogStats {
+	return AccessLogStats{Skipped: accessSkipped.Load(), Sampled: accessSampled.Load()}
+}
+
+// WithAccessLogPolicy sets the policy that decides which successful requests are logged.
+// By default, all requests are logged.
+func WithAccessLogPolicy(p AccessLogPolicy) MiddlewareOption {
+	if p.BurstPeriod <= 0 {
+		p.BurstPeriod = time.Second
+	}
+	return func(c *middlewareConfig) {
+		c.access = &accessLog{policy: p}
+	}
+}
+
+// accessLog applies an AccessLogPolicy. Each route has its own sampler, so busy routes don't crowd out the others.
+type accessLog struct {
+	policy   AccessLogPolicy
+	samplers sync.Map // route -> zerolog.Sampler
+}
+
+// keep reports whether the successful request is logged, and counts the ones that are not.
+func (a *accessLog) keep(ctx *gin.Context, latency time.Duration) bool {
+	if a.policy.SlowThreshold > 0 && latency >= a.policy.SlowThreshold {
+		return true
+	}
+
+	route := ctx.FullPath()
+	for _, pattern := range a.policy.SkipPaths {
+		if ok, _ := path.Match(pattern, route); ok && len(route) > 0 {
+			accessSkipped.Add(1)
+			return false
+		}
+		if ok, _ := path.Match(pattern, ctx.Request.URL.Path); ok {
+			accessSkipped.Add(1)
+			return false
+		}
+	}
+
+	s := a.sampler(route)
+	if s != nil && !s.Sample(zerolog.InfoLevel) {
+		accessSampled.Add(1)
+		return false
+	}
+	return true
+}
+
+// sampler returns the sampler of the route, or nil if the requests are not sampled.
+func (a *accessLog) sampler(route string) zerolog.Sampler {
+	if a.policy.SampleEvery <= 1 && a.policy.Burst == 0 {
+		return nil
+	}
+	if s, ok := a.samplers.Load(route); ok {
+		return s.(zerolog.Sampler)
+	}
+
+	var s zerolog.Sampler
+	if a.policy.SampleEvery > 1 {
+		s = &zerolog.BasicSampler{N: a.policy.SampleEvery}
+	}
+	if a.policy.Burst > 0 {
+		s = &zerolog.BurstSampler{Burst: a.policy.Burst, Period: a.policy.BurstPeriod, NextSampler: s}
+	}
+	actual, _ := a.samplers.LoadOrStore(route, s)
+	return actual.(zerolog.Sampler)
+}
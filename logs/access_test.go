@@ -0,0 +1,89 @@
+package logs_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+func newAccessLogRouter(t *testing.T, p logs.AccessLogPolicy) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	r, tout := newLoggingRouter(t, logs.WithAccessLogPolicy(p))
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/debug/vars", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/person/:id", func(c *gin.Context) {
+		if c.Query("fail") == "true" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if c.Query("slow") == "true" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/order/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, tout
+}
+
+func get(r *gin.Engine, target string, n int) {
+	for i := 0; i < n; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+}
+
+func logLines(tout *bytes.Buffer) int {
+	return strings.Count(tout.String(), "\n")
+}
+
+func TestAccessLogPolicy_SkipPaths(t *testing.T) {
+	r, tout := newAccessLogRouter(t, logs.AccessLogPolicy{SkipPaths: []string{"/health", "/debug/*"}})
+
+	get(r, "/health", 3)
+	get(r, "/debug/vars", 1)
+	assert.Equal(t, 0, logLines(tout))
+
+	get(r, "/person/1", 1)
+	assert.Equal(t, 1, logLines(tout))
+	assert.Equal(t, logs.AccessLogStats{Skipped: 4}, logs.DroppedAccessLogs())
+}
+
+func TestAccessLogPolicy_SampleEvery(t *testing.T) {
+	r, tout := newAccessLogRouter(t, logs.AccessLogPolicy{SampleEvery: 5})
+
+	// each route is sampled on its own, with distinct paths of a route sharing the sampler.
+	for i := 0; i < 10; i++ {
+		get(r, "/person/"+strings.Repeat("1", i+1), 1)
+	}
+	get(r, "/order/1", 1)
+	assert.Equal(t, 3, logLines(tout))
+	assert.Equal(t, logs.AccessLogStats{Sampled: 8}, logs.DroppedAccessLogs())
+
+	// failures and slow requests are not sampled out.
+	get(r, "/person/1?fail=true", 5)
+	assert.Equal(t, 8, logLines(tout))
+}
+
+func TestAccessLogPolicy_SlowThreshold(t *testing.T) {
+	r, tout := newAccessLogRouter(t, logs.AccessLogPolicy{SkipPaths: []string{"/person/:id"}, SlowThreshold: 10 * time.Millisecond})
+
+	get(r, "/person/1", 2)
+	assert.Equal(t, 0, logLines(tout))
+	get(r, "/person/1?slow=true", 1)
+	assert.Equal(t, 1, logLines(tout))
+}
+
+func TestAccessLogPolicy_Burst(t *testing.T) {
+	r, tout := newAccessLogRouter(t, logs.AccessLogPolicy{Burst: 2, BurstPeriod: time.Hour})
+
+	get(r, "/person/1", 5)
+	get(r, "/order/1", 5)
+	assert.Equal(t, 4, logLines(tout))
+	assert.Equal(t, logs.AccessLogStats{Sampled: 6}, logs.DroppedAccessLogs())
+}
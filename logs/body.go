@@ -0,0 +1,222 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HttpRequestBody           = "http.request.body"
+	HttpRequestBodyTruncated  = "http.request.body.truncated"
+	HttpResponseBody          = "http.response.body"
+	HttpResponseBodyTruncated = "http.response.body.truncated"
+
+	// DefaultBodyMaxBytes is the number of bytes captured per body when BodyCapture.MaxBytes is not set.
+	DefaultBodyMaxBytes = 4096
+)
+
+// DefaultBodyContentTypes are the media types captured when BodyCapture.ContentTypes is not set.
+var DefaultBodyContentTypes = []string{"application/json", "application/*+json", "text/*"}
+
+// BodyCapture configures the capture of the request and response bodies by the logging middleware.
+type BodyCapture struct {
+	// MaxBytes bounds the number of bytes captured per body. The default is DefaultBodyMaxBytes.
+	MaxBytes int
+	// ContentTypes lists the media types of the bodies that are captured. Wildcards are supported, e.g., "text/*".
+	// The default is DefaultBodyContentTypes.
+	ContentTypes []string
+	// Routes lists the gin route templates, e.g., /person/:id, for which bodies are captured. Wildcards are supported,
+	// e.g., /admin/*. If empty, bodies are captured for all routes.
+	Routes []string
+	// Redact lists the key paths of the JSON fields whose values are replaced with ****, e.g., "password" or
+	// "card.number". Arrays are traversed, so "items.token" applies to every element of items. Keys are
+	// case-insensitive. If a JSON body can't be parsed, e.g., because it was truncated, it is not logged.
+	Redact []string
+	// OnlyOnFailure logs the bodies only for failed requests, i.e., the status is 500 or above, or errors
+	// were added to the gin context.
+	OnlyOnFailure bool
+}
+
+// WithBodyCapture logs the request and response bodies, as http.request.body and http.response.body.
+// Bodies are not captured unless this option is supplied.
+func WithBodyCapture(bc BodyCapture) MiddlewareOption {
+	if bc.MaxBytes <= 0 {
+		bc.MaxBytes = DefaultBodyMaxBytes
+	}
+	if len(bc.ContentTypes) == 0 {
+		bc.ContentTypes = DefaultBodyContentTypes
+	}
+	return func(c *middlewareConfig) {
+		c.body = &bc
+	}
+}
+
+// bodyRecorder holds the bodies captured for a request.
+type bodyRecorder struct {
+	bc       *BodyCapture
+	request  []byte
+	reqTrunc bool
+	writer   *bodyWriter
+}
+
+// start captures the beginning of the request body, and wraps the response writer. The request body is
+// put back together so handlers read it in full.
+func (bc *BodyCapture) start(ctx *gin.Context) *bodyRecorder {
+	if !bc.matchesRoute(ctx.FullPath()) {
+		return nil
+	}
+
+	rec := &bodyRecorder{bc: bc}
+	if body := ctx.Request.Body; body != nil && body != http.NoBody && bc.matchesContentType(ctx.ContentType()) {
+		prefix, _ := io.ReadAll(io.LimitReader(body, int64(bc.MaxBytes)+1))
+		ctx.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), body), Closer: body}
+		rec.request, rec.reqTrunc = truncateBody(prefix, bc.MaxBytes)
+	}
+
+	rec.writer = &bodyWriter{ResponseWriter: ctx.Writer, limit: bc.MaxBytes}
+	ctx.Writer = rec.writer
+	return rec
+}
+
+// fields returns the captured bodies as log fields, and restores the response writer.
+func (rec *bodyRecorder) fields(ctx *gin.Context, failed bool) map[string]any {
+	ctx.Writer = rec.writer.ResponseWriter
+	if rec.bc.OnlyOnFailure && !failed {
+		return nil
+	}
+
+	args := make(map[string]any, 4)
+	if body, ok := rec.bc.redact(rec.request, ctx.ContentType()); ok && len(body) > 0 {
+		args[HttpRequestBody] = body
+		if rec.reqTrunc {
+			args[HttpRequestBodyTruncated] = true
+		}
+	}
+
+	ct := rec.writer.Header().Get("Content-Type")
+	if !rec.bc.matchesContentType(ct) {
+		return args
+	}
+	if body, ok := rec.bc.redact(rec.writer.buf.Bytes(), ct); ok && len(body) > 0 {
+		args[HttpResponseBody] = body
+		if rec.writer.truncated {
+			args[HttpResponseBodyTruncated] = true
+		}
+	}
+	return args
+}
+
+func (bc *BodyCapture) matchesRoute(route string) bool {
+	if len(bc.Routes) == 0 {
+		return true
+	}
+	for _, pattern := range bc.Routes {
+		if ok, _ := path.Match(pattern, route); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (bc *BodyCapture) matchesContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range bc.ContentTypes {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redact applies the Redact key paths to JSON bodies. It returns false if the body must not be logged.
+func (bc *BodyCapture) redact(body []byte, contentType string) (string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(bc.Redact) == 0 || len(body) == 0 || !strings.HasSuffix(mediaType, "json") {
+		return string(body), true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return "", false
+	}
+	for _, p := range bc.Redact {
+		redactPath(doc, strings.Split(p, "."))
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return "", false
+	}
+	return string(redacted), true
+}
+
+func redactPath(node any, keys []string) {
+	switch n := node.(type) {
+	case []any:
+		for _, e := range n {
+			redactPath(e, keys)
+		}
+	case map[string]any:
+		for k, v := range n {
+			if !strings.EqualFold(k, keys[0]) {
+				continue
+			}
+			if len(keys) == 1 {
+				n[k] = maskedValue
+				continue
+			}
+			redactPath(v, keys[1:])
+		}
+	}
+}
+
+func truncateBody(b []byte, limit int) ([]byte, bool) {
+	if len(b) > limit {
+		return b[:limit], true
+	}
+	return b, false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyWriter copies the first bytes written to the response.
+type bodyWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(b []byte) {
+	room := w.limit - w.buf.Len()
+	if len(b) > room {
+		b = b[:room]
+		w.truncated = true
+	}
+	w.buf.Write(b)
+}
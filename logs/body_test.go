@@ -0,0 +1,97 @@
+package logs_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+const personJSON = `{"name":"jane","password":"hunter2","card":{"number":"4111111111111111","exp":"12/30"},"items":[{"token":"t1"},{"token":"t2"}]}`
+
+// serveBody posts body to /person/:id, whose handler echoes it back with the status, and returns the log entry
+// and the body the handler received.
+func serveBody(t *testing.T, target, contentType, body string, status int, opts ...logs.MiddlewareOption) (le map[string]any, received string) {
+	t.Helper()
+	r, tout := newLoggingRouter(t, opts...)
+	echo := func(c *gin.Context) {
+		b, _ := io.ReadAll(c.Request.Body)
+		received = string(b)
+		c.Data(status, contentType, b)
+	}
+	r.POST("/person/:id", echo)
+	r.POST("/upload", echo)
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w, le := serveEntry(t, r, tout, req)
+	assert.Equal(t, body, w.Body.String())
+	return le, received
+}
+
+func TestGinLoggingMiddleware_NoBodyCapture(t *testing.T) {
+	le, received := serveBody(t, "/person/1", "application/json", personJSON, http.StatusOK)
+	assert.Equal(t, personJSON, received)
+	assert.NotContains(t, le, logs.HttpRequestBody)
+	assert.NotContains(t, le, logs.HttpResponseBody)
+}
+
+func TestGinLoggingMiddleware_BodyCapture(t *testing.T) {
+	le, received := serveBody(t, "/person/1", "application/json; charset=utf-8", personJSON, http.StatusOK,
+		logs.WithBodyCapture(logs.BodyCapture{Redact: []string{"password", "card.number", "items.token"}}))
+
+	assert.Equal(t, personJSON, received)
+	expected := `{"card":{"exp":"12/30","number":"****"},"items":[{"token":"****"},{"token":"****"}],"name":"jane","password":"****"}`
+	assert.Equal(t, expected, le[logs.HttpRequestBody])
+	assert.Equal(t, expected, le[logs.HttpResponseBody])
+	assert.NotContains(t, le, logs.HttpRequestBodyTruncated)
+}
+
+func TestGinLoggingMiddleware_BodyCaptureTruncated(t *testing.T) {
+	body := strings.Repeat("a", 20)
+	le, received := serveBody(t, "/person/1", "text/plain", body, http.StatusOK,
+		logs.WithBodyCapture(logs.BodyCapture{MaxBytes: 8}))
+
+	assert.Equal(t, body, received)
+	assert.Equal(t, "aaaaaaaa", le[logs.HttpRequestBody])
+	assert.Equal(t, true, le[logs.HttpRequestBodyTruncated])
+	assert.Equal(t, "aaaaaaaa", le[logs.HttpResponseBody])
+	assert.Equal(t, true, le[logs.HttpResponseBodyTruncated])
+
+	// truncated JSON can't be redacted, so it is not logged.
+	le, _ = serveBody(t, "/person/1", "application/json", personJSON, http.StatusOK,
+		logs.WithBodyCapture(logs.BodyCapture{MaxBytes: 8, Redact: []string{"password"}}))
+	assert.NotContains(t, le, logs.HttpRequestBody)
+	assert.NotContains(t, le, logs.HttpResponseBody)
+}
+
+func TestGinLoggingMiddleware_BodyCaptureFilters(t *testing.T) {
+	bc := logs.BodyCapture{Routes: []string{"/person/*"}, ContentTypes: []string{"application/json"}}
+
+	le, _ := serveBody(t, "/upload", "application/json", `{"a":1}`, http.StatusOK, logs.WithBodyCapture(bc))
+	assert.NotContains(t, le, logs.HttpRequestBody, "route not listed")
+
+	le, _ = serveBody(t, "/person/1", "application/octet-stream", "binary", http.StatusOK, logs.WithBodyCapture(bc))
+	assert.NotContains(t, le, logs.HttpRequestBody, "content type not listed")
+	assert.NotContains(t, le, logs.HttpResponseBody, "content type not listed")
+
+	le, _ = serveBody(t, "/person/1", "application/json", `{"a":1}`, http.StatusOK, logs.WithBodyCapture(bc))
+	assert.Equal(t, `{"a":1}`, le[logs.HttpRequestBody])
+}
+
+func TestGinLoggingMiddleware_BodyCaptureOnlyOnFailure(t *testing.T) {
+	bc := logs.WithBodyCapture(logs.BodyCapture{OnlyOnFailure: true})
+
+	le, _ := serveBody(t, "/person/1", "application/json", `{"a":1}`, http.StatusOK, bc)
+	assert.NotContains(t, le, logs.HttpRequestBody)
+	assert.NotContains(t, le, logs.HttpResponseBody)
+
+	le, _ = serveBody(t, "/person/1", "application/json", `{"a":1}`, http.StatusBadGateway, bc)
+	assert.Equal(t, `{"a":1}`, le[logs.HttpRequestBody])
+	assert.Equal(t, `{"a":1}`, le[logs.HttpResponseBody])
+}
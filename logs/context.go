@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxLoggerKey struct{}
+
+// FromContext returns the logger of the request, with the trace id and span id found in the ctx. The logging
+// middleware puts a logger with the request fields in the request context, and With adds fields to it.
+// If the ctx has none, the logger of the logging system is used.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	l := ctxLogger(ctx).With().Fields(traceInfo(ctx)).Logger()
+	return &l
+}
+
+// With returns a copy of ctx whose logger has the fields added. The fields are written by all subsequent log
+// calls made with the returned ctx, including the helper funcs and the access log entry of the request.
+// In a gin handler, replace the request so the fields flow to the middleware:
+//
+//	c.Request = c.Request.WithContext(logs.With(c.Request.Context(), map[string]any{"user.id": id}))
+func With(ctx context.Context, fields map[string]any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l := storedLogger(ctx).With().Fields(fields).Logger()
+	return context.WithValue(ctx, ctxLoggerKey{}, &l)
+}
+
+// ctxLogger returns the logger stored in the ctx, or the logger of the logging system, whose exported
+// records carry the span context found in the ctx.
+func ctxLogger(ctx context.Context) *zerolog.Logger {
+	l := storedLogger(ctx)
+	if ctx == nil {
+		return l
+	}
+	return withSpan(l, trace.SpanContextFromContext(requestContext(ctx)))
+}
+
+// storedLogger returns the logger stored in the ctx, or the logger of the logging system.
+func storedLogger(ctx context.Context) *zerolog.Logger {
+	ctx = requestContext(ctx)
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxLoggerKey{}).(*zerolog.Logger); ok {
+			return l
+		}
+	}
+	return &logger
+}
+
+// requestContext returns the context of the request if ctx is a *gin.Context, whose Value func doesn't
+// look into the request context unless the engine is configured to.
+func requestContext(ctx context.Context) context.Context {
+	if gc, ok := ctx.(*gin.Context); ok && gc.Request != nil {
+		return gc.Request.Context()
+	}
+	return ctx
+}
@@ -0,0 +1,75 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"github.com/twistingmercury/monitoring/traces"
+	"github.com/twistingmercury/monitoring/traces/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFromContext(t *testing.T) {
+	tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithCorrelation(logs.OTelCorrelation()))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logs.GinLoggingMiddleware(), traces.GinTracingMiddleware())
+
+	var sc trace.SpanContext
+	r.GET("/person/:id", func(c *gin.Context) {
+		sc = trace.SpanContextFromContext(c.Request.Context())
+		c.Request = c.Request.WithContext(logs.With(c.Request.Context(), map[string]any{"user.id": "u-42"}))
+		logs.FromContext(c.Request.Context()).Info().Msg("from context")
+		logs.Warn(c, "from helper", map[string]any{"tenant": "acme"})
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/person/1", nil))
+
+	lines := strings.Split(strings.TrimSpace(tout.String()), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.Equal(t, 1, strings.Count(line, `"`+logs.HttpMethod+`"`), "duplicate field: %s", line)
+		assert.Equal(t, 1, strings.Count(line, `"trace_id"`), "duplicate field: %s", line)
+
+		le := make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(line), &le))
+		assert.Equal(t, http.MethodGet, le[logs.HttpMethod])
+		assert.Equal(t, "/person/1", le[logs.HttpPath])
+		assert.Equal(t, "u-42", le["user.id"])
+		assert.Equal(t, sc.TraceID().String(), le["trace_id"])
+		assert.Equal(t, sc.SpanID().String(), le["span_id"])
+	}
+	assert.Contains(t, lines[1], `"tenant":"acme"`)
+	assert.Contains(t, lines[2], "request successful")
+}
+
+func TestFromContext_NoRequest(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	logs.FromContext(context.Background()).Info().Msg("no request")
+	ctx := logs.With(context.Background(), map[string]any{"job": "nightly"})
+	logs.Info(ctx, "job started", nil)
+	logs.Info(context.Background(), "unrelated", nil)
+
+	lines := strings.Split(strings.TrimSpace(tout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"service":"logs_test"`)
+	assert.Contains(t, lines[0], `"dd.trace_id":"0"`)
+	assert.Contains(t, lines[1], `"job":"nightly"`)
+	assert.NotContains(t, lines[2], "nightly")
+}
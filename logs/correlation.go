@@ -0,0 +1,120 @@
+package logs
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Correlation describes how the trace context is written to log entries so the backend can correlate
+// logs and traces. The predefined profiles cover the common backends; a custom profile can be defined
+// for others.
+type Correlation struct {
+	// TraceIDKey is the key of the trace id field.
+	TraceIDKey string
+	// SpanIDKey is the key of the span id field.
+	SpanIDKey string
+	// TraceFlagsKey is the key of the trace flags field, written as two hex digits, e.g., "01". Optional.
+	TraceFlagsKey string
+	// SampledKey is the key of the boolean field that tells if the trace is sampled. Optional.
+	SampledKey string
+	// FormatTraceID formats the trace id. The default is 32 lowercase hex digits.
+	FormatTraceID func(trace.TraceID) string
+	// FormatSpanID formats the span id. The default is 16 lowercase hex digits.
+	FormatSpanID func(trace.SpanID) string
+}
+
+// DatadogCorrelation writes the ids to dd.trace_id and dd.span_id in the 64-bit decimal form Datadog expects.
+// The trace id is the lower 64 bits of the OTel trace id. This is the default.
+func DatadogCorrelation() Correlation {
+	return Correlation{
+		TraceIDKey: TraceIDAttr,
+		SpanIDKey:  SpanIDAttr,
+		FormatTraceID: func(tid trace.TraceID) string {
+			return strconv.FormatUint(binary.BigEndian.Uint64(tid[8:]), 10)
+		},
+		FormatSpanID: func(sid trace.SpanID) string {
+			return strconv.FormatUint(binary.BigEndian.Uint64(sid[:]), 10)
+		},
+	}
+}
+
+// OTelCorrelation writes the ids to trace_id and span_id, and the flags to trace_flags, in hex, as defined
+// by the OTel specification for logs.
+func OTelCorrelation() Correlation {
+	return Correlation{
+		TraceIDKey:    "trace_id",
+		SpanIDKey:     "span_id",
+		TraceFlagsKey: "trace_flags",
+	}
+}
+
+// GCPCorrelation writes the ids to the fields Google Cloud Logging uses to correlate entries with
+// Cloud Trace. The trace id is prefixed with projects/<projectID>/traces/.
+func GCPCorrelation(projectID string) Correlation {
+	return Correlation{
+		TraceIDKey: "logging.googleapis.com/trace",
+		SpanIDKey:  "logging.googleapis.com/spanId",
+		SampledKey: "logging.googleapis.com/trace_sampled",
+		FormatTraceID: func(tid trace.TraceID) string {
+			return "projects/" + projectID + "/traces/" + tid.String()
+		},
+	}
+}
+
+// XRayCorrelation writes the trace id in the AWS X-Ray format, e.g., 1-5759e988-bd862e3fe1be46a994272793,
+// to xray_trace_id, and the span id to xray_segment_id.
+func XRayCorrelation() Correlation {
+	return Correlation{
+		TraceIDKey: "xray_trace_id",
+		SpanIDKey:  "xray_segment_id",
+		FormatTraceID: func(tid trace.TraceID) string {
+			return "1-" + hex.EncodeToString(tid[:4]) + "-" + hex.EncodeToString(tid[4:])
+		},
+	}
+}
+
+// ECSCorrelation writes the ids to trace.id and span.id, as defined by the Elastic Common Schema.
+func ECSCorrelation() Correlation {
+	return Correlation{
+		TraceIDKey: "trace.id",
+		SpanIDKey:  "span.id",
+	}
+}
+
+// WithCorrelation sets the profile used to write the trace context to log entries.
+// The default is DatadogCorrelation.
+func WithCorrelation(c Correlation) Option {
+	return func(cfg *config) {
+		cfg.correlation = c
+	}
+}
+
+// fields returns the log fields for the span context. If the span context is not valid, the zero ids are written.
+func (c Correlation) fields(sc trace.SpanContext) map[string]any {
+	tMap := make(map[string]any, 4)
+
+	tid := sc.TraceID()
+	if c.FormatTraceID != nil {
+		tMap[c.TraceIDKey] = c.FormatTraceID(tid)
+	} else {
+		tMap[c.TraceIDKey] = tid.String()
+	}
+
+	sid := sc.SpanID()
+	if c.FormatSpanID != nil {
+		tMap[c.SpanIDKey] = c.FormatSpanID(sid)
+	} else {
+		tMap[c.SpanIDKey] = sid.String()
+	}
+
+	if len(c.TraceFlagsKey) > 0 {
+		tMap[c.TraceFlagsKey] = sc.TraceFlags().String()
+	}
+	if len(c.SampledKey) > 0 {
+		tMap[c.SampledKey] = sc.IsSampled()
+	}
+	return tMap
+}
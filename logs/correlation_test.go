@@ -0,0 +1,121 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func testSpanContext(t *testing.T, sampled bool) trace.SpanContext {
+	t.Helper()
+	tid, err := trace.TraceIDFromHex("5759e988bd862e3fe1be46a994272793")
+	require.NoError(t, err)
+	sid, err := trace.SpanIDFromHex("53995c3f42cd8ad8")
+	require.NoError(t, err)
+
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: flags})
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		name        string
+		correlation logs.Correlation
+		sampled     bool
+		expected    map[string]any
+	}{
+		{
+			name:        "datadog",
+			correlation: logs.DatadogCorrelation(),
+			expected: map[string]any{
+				logs.TraceIDAttr: "16266516598257821587",
+				logs.SpanIDAttr:  "6023947403358210776",
+			},
+		},
+		{
+			name:        "otel",
+			correlation: logs.OTelCorrelation(),
+			sampled:     true,
+			expected: map[string]any{
+				"trace_id":    "5759e988bd862e3fe1be46a994272793",
+				"span_id":     "53995c3f42cd8ad8",
+				"trace_flags": "01",
+			},
+		},
+		{
+			name:        "gcp",
+			correlation: logs.GCPCorrelation("my-project"),
+			sampled:     true,
+			expected: map[string]any{
+				"logging.googleapis.com/trace":         "projects/my-project/traces/5759e988bd862e3fe1be46a994272793",
+				"logging.googleapis.com/spanId":        "53995c3f42cd8ad8",
+				"logging.googleapis.com/trace_sampled": true,
+			},
+		},
+		{
+			name:        "xray",
+			correlation: logs.XRayCorrelation(),
+			expected: map[string]any{
+				"xray_trace_id":   "1-5759e988-bd862e3fe1be46a994272793",
+				"xray_segment_id": "53995c3f42cd8ad8",
+			},
+		},
+		{
+			name:        "ecs",
+			correlation: logs.ECSCorrelation(),
+			expected: map[string]any{
+				"trace.id": "5759e988bd862e3fe1be46a994272793",
+				"span.id":  "53995c3f42cd8ad8",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tout := &bytes.Buffer{}
+			logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout, logs.WithCorrelation(tt.correlation))
+
+			ctx := trace.ContextWithSpanContext(context.Background(), testSpanContext(t, tt.sampled))
+			logs.Info(ctx, "correlated", nil)
+
+			le := make(map[string]any)
+			require.NoError(t, json.Unmarshal(tout.Bytes(), &le))
+			for k, v := range tt.expected {
+				assert.Equal(t, v, le[k], k)
+			}
+		})
+	}
+}
+
+func TestCorrelation_GinKeysFallback(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logs.GinLoggingMiddleware())
+	r.GET("/test", func(c *gin.Context) {
+		c.Set("trace_id", "5759e988bd862e3fe1be46a994272793")
+		c.Set("span_id", "53995c3f42cd8ad8")
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	le := make(map[string]any)
+	require.NoError(t, json.Unmarshal(tout.Bytes(), &le))
+	assert.Equal(t, "16266516598257821587", le[logs.TraceIDAttr])
+	assert.Equal(t, "6023947403358210776", le[logs.SpanIDAttr])
+}
@@ -0,0 +1,200 @@
+package logs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultDeniedHeaders are the headers that are never logged by DefaultHeaderPolicy, because they carry credentials.
+var DefaultDeniedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+	"X-Amz-Security-Token",
+}
+
+const (
+	// RequestHeaderPrefix is the prefix of the keys of the logged request headers.
+	RequestHeaderPrefix = "http.request.header."
+	// ResponseHeaderPrefix is the prefix of the keys of the logged response headers.
+	ResponseHeaderPrefix = "http.response.header."
+
+	maskedValue = "****"
+)
+
+// HeaderPolicy controls which headers are logged and how their values are written.
+// Header names are case-insensitive.
+type HeaderPolicy struct {
+	// Allow, if not empty, lists the only headers that are logged, and Deny is ignored.
+	Allow []string
+	// Deny lists the headers that are never logged, in addition to DefaultDeniedHeaders.
+	Deny []string
+	// DisableDefaultDeny logs the DefaultDeniedHeaders that are not in Deny. By default, they are denied by
+	// every policy, so a policy built to mask or hash a header does not log the credentials of the others.
+	DisableDefaultDeny bool
+	// Mask lists the headers whose values are masked: only the auth scheme, if any, and the last 4 characters
+	// of the value are kept, e.g., "Bearer ****abcd". The auth scheme is the first word of the value if it is a
+	// bare token, as in the Authorization header; other values, e.g., cookies, are masked entirely but for their
+	// last 4 characters. Masked headers are logged even if they are denied or not allowed.
+	Mask []string
+	// Hash lists the headers whose values are replaced with their sha256 hash, so requests can be correlated
+	// without disclosing the value. Hashed headers are logged even if they are denied or not allowed.
+	Hash []string
+	// MaxLength truncates the values, other than masked and hashed ones, longer than MaxLength bytes. Zero means no limit.
+	MaxLength int
+}
+
+// DefaultHeaderPolicy returns the policy used when none is supplied: all headers but DefaultDeniedHeaders are logged.
+func DefaultHeaderPolicy() HeaderPolicy {
+	return HeaderPolicy{Deny: append([]string(nil), DefaultDeniedHeaders...)}
+}
+
+// WithHeaderPolicy sets the policy the logging middleware applies to the request headers.
+// The default is DefaultHeaderPolicy.
+func WithHeaderPolicy(p HeaderPolicy) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.headers = p.rules()
+	}
+}
+
+// WithResponseHeaders logs the response headers listed, as http.response.header.<name> attributes.
+// The header policy applies to them too.
+func WithResponseHeaders(names ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.responseHeaders = append(c.responseHeaders, names...)
+	}
+}
+
+// ParseHeaders applies the policy to the request headers and returns a map of attributes. The keys are
+// http.request.header.<name>, with the name lower-cased, and the values are the header values, as sent.
+func (p HeaderPolicy) ParseHeaders(headers map[string][]string) map[string]any {
+	return p.rules().apply(headers, RequestHeaderPrefix)
+}
+
+var defaultHeaderRules = DefaultHeaderPolicy().rules()
+
+// headerRules is a HeaderPolicy indexed by canonical header name.
+type headerRules struct {
+	allow     map[string]bool
+	deny      map[string]bool
+	mask      map[string]bool
+	hash      map[string]bool
+	maxLength int
+}
+
+func (p HeaderPolicy) rules() headerRules {
+	deny := p.Deny
+	if !p.DisableDefaultDeny {
+		deny = append(append([]string(nil), DefaultDeniedHeaders...), deny...)
+	}
+	return headerRules{
+		allow:     headerSet(p.Allow),
+		deny:      headerSet(deny),
+		mask:      headerSet(p.Mask),
+		hash:      headerSet(p.Hash),
+		maxLength: p.MaxLength,
+	}
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[http.CanonicalHeaderKey(n)] = true
+	}
+	return set
+}
+
+func (r headerRules) apply(headers map[string][]string, prefix string) map[string]any {
+	args := make(map[string]any)
+	for k, v := range headers {
+		name := http.CanonicalHeaderKey(k)
+		var transform func(string) string
+		switch {
+		case r.hash[name]:
+			transform = hashValue
+		case r.mask[name]:
+			transform = maskValue
+		case len(r.allow) > 0 && !r.allow[name], len(r.allow) == 0 && r.deny[name]:
+			continue
+		default:
+			transform = r.truncate
+		}
+
+		values := make([]string, len(v))
+		for i, val := range v {
+			values[i] = transform(val)
+		}
+		args[prefix+strings.ToLower(name)] = values
+	}
+	return args
+}
+
+func (r headerRules) truncate(val string) string {
+	if r.maxLength <= 0 || len(val) <= r.maxLength {
+		return val
+	}
+	val = val[:r.maxLength]
+	for len(val) > 0 && !utf8.ValidString(val) {
+		val = val[:len(val)-1]
+	}
+	return val
+}
+
+// maskValue keeps the auth scheme, if any, and the last 4 characters of values long enough
+// that doing so does not disclose most of the secret.
+func maskValue(val string) string {
+	scheme, secret, found := strings.Cut(val, " ")
+	if !found || !isToken(scheme) {
+		scheme, secret = "", val
+	}
+
+	masked := maskedValue
+	if len(secret) >= 12 {
+		masked += secret[len(secret)-4:]
+	}
+	if len(scheme) > 0 {
+		return scheme + " " + masked
+	}
+	return masked
+}
+
+// isToken reports whether s is an RFC 7230 token, as the auth schemes are. Values such as "session=abc;"
+// are not.
+func isToken(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func hashValue(val string) string {
+	sum := sha256.Sum256([]byte(val))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// selectHeaders returns the headers listed in names.
+func selectHeaders(headers http.Header, names []string) http.Header {
+	selected := make(http.Header, len(names))
+	for _, n := range names {
+		if v := headers.Values(n); len(v) > 0 {
+			selected[http.CanonicalHeaderKey(n)] = v
+		}
+	}
+	return selected
+}
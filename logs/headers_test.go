@@ -0,0 +1,151 @@
+package logs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+const (
+	bearerToken = "eyJhbGciOiJIUzI1NiJ9.c2VjcmV0.Sflabcd"
+	cookieValue = "session=8f14e45fceea167a5a36dedd4bea2543"
+	apiKey      = "ak_live_51HqLyjWDarjtT1zdp7dc"
+)
+
+// serveWithHeaders logs a request with credentials in its headers and returns the raw log entry.
+func serveWithHeaders(t *testing.T, opts ...logs.MiddlewareOption) (raw string, le map[string]any) {
+	t.Helper()
+	r, tout := newLoggingRouter(t, opts...)
+	r.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+	req.Header.Set("Cookie", cookieValue)
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	_, le = serveEntry(t, r, tout, req)
+	return tout.String(), le
+}
+
+func assertNoSecrets(t *testing.T, raw string) {
+	t.Helper()
+	for _, secret := range []string{bearerToken, cookieValue, apiKey, strings.ToLower(bearerToken), strings.ToLower(apiKey)} {
+		assert.NotContains(t, raw, secret)
+	}
+}
+
+func TestGinLoggingMiddleware_DefaultHeaderPolicy(t *testing.T) {
+	raw, le := serveWithHeaders(t)
+
+	assertNoSecrets(t, raw)
+	assert.NotContains(t, le, "http.request.header.authorization")
+	assert.NotContains(t, le, "http.request.header.cookie")
+	assert.NotContains(t, le, "http.request.header.x-api-key")
+	assert.Equal(t, []any{"application/json"}, le["http.request.header.accept"])
+	assert.Equal(t, []any{"acme"}, le["http.request.header.x-tenant"])
+}
+
+func TestGinLoggingMiddleware_AllowList(t *testing.T) {
+	raw, le := serveWithHeaders(t, logs.WithHeaderPolicy(logs.HeaderPolicy{Allow: []string{"accept"}}))
+
+	assertNoSecrets(t, raw)
+	assert.Equal(t, []any{"application/json"}, le["http.request.header.accept"])
+	assert.NotContains(t, le, "http.request.header.x-tenant")
+}
+
+func TestGinLoggingMiddleware_MaskAndHash(t *testing.T) {
+	p := logs.DefaultHeaderPolicy()
+	p.Mask = []string{"authorization"}
+	p.Hash = []string{"X-Api-Key"}
+	p.MaxLength = 3
+	raw, le := serveWithHeaders(t, logs.WithHeaderPolicy(p))
+
+	assertNoSecrets(t, raw)
+	assert.Equal(t, []any{"Bearer ****abcd"}, le["http.request.header.authorization"])
+	assert.NotContains(t, le, "http.request.header.cookie")
+	assert.Equal(t, []any{"app"}, le["http.request.header.accept"])
+
+	hashed := logs.HeaderPolicy{Hash: []string{"X-Api-Key"}}.ParseHeaders(map[string][]string{"X-Api-Key": {apiKey}})
+	require.Len(t, hashed["http.request.header.x-api-key"], 1)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", hashed["http.request.header.x-api-key"].([]string)[0])
+	assert.Equal(t, hashed["http.request.header.x-api-key"], logs.HeaderPolicy{Hash: []string{"x-api-key"}}.ParseHeaders(map[string][]string{"x-api-key": {apiKey}})["http.request.header.x-api-key"])
+}
+
+func TestGinLoggingMiddleware_PolicyKeepsDefaultDeny(t *testing.T) {
+	raw, le := serveWithHeaders(t, logs.WithHeaderPolicy(logs.HeaderPolicy{Mask: []string{"X-Api-Key"}}))
+
+	assertNoSecrets(t, raw)
+	assert.NotContains(t, le, "http.request.header.authorization")
+	assert.NotContains(t, le, "http.request.header.cookie")
+	assert.Equal(t, []any{"****p7dc"}, le["http.request.header.x-api-key"])
+
+	_, le = serveWithHeaders(t, logs.WithHeaderPolicy(logs.HeaderPolicy{DisableDefaultDeny: true, Deny: []string{"Cookie"}}))
+	assert.Equal(t, []any{"Bearer " + bearerToken}, le["http.request.header.authorization"])
+	assert.NotContains(t, le, "http.request.header.cookie")
+}
+
+func TestParseHeaders_Mask(t *testing.T) {
+	p := logs.HeaderPolicy{Mask: []string{"Authorization", "Cookie"}}
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{name: "bearer", header: "Authorization", value: "Bearer " + bearerToken, want: "Bearer ****abcd"},
+		{name: "basic", header: "Authorization", value: "Basic dXNlcjpwYXNzd29yZA==", want: "Basic ****ZA=="},
+		{name: "no scheme", header: "Authorization", value: bearerToken, want: "****abcd"},
+		{name: "cookie", header: "Cookie", value: cookieValue + "; theme=dark", want: "****dark"},
+		{name: "single cookie", header: "Cookie", value: cookieValue, want: "****2543"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := p.ParseHeaders(map[string][]string{tt.header: {tt.value}})
+			assert.Equal(t, []string{tt.want}, args["http.request.header."+strings.ToLower(tt.header)])
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	args := logs.ParseHeaders(map[string][]string{
+		"Authorization": {"Basic dXNlcjpwYXNz"},
+		"Set-Cookie":    {"a=b"},
+		"Accept":        {"text/html", "application/json"},
+	})
+	assert.Equal(t, map[string]any{"http.request.header.accept": []string{"text/html", "application/json"}}, args)
+
+	masked := logs.HeaderPolicy{Mask: []string{"Authorization"}}.ParseHeaders(map[string][]string{"Authorization": {"short"}})
+	assert.Equal(t, []string{"****"}, masked["http.request.header.authorization"])
+}
+
+func TestGinLoggingMiddleware_HeaderValues(t *testing.T) {
+	r, tout := newLoggingRouter(t, logs.WithResponseHeaders("ETag", "set-cookie", "X-Missing"))
+	r.GET("/test", func(c *gin.Context) {
+		c.Header("ETag", `W/"0815AbC"`)
+		c.Header("Set-Cookie", "session=secret")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Request-ID", "01HQ3Z8KX7Ab")
+	req.Header.Set("If-None-Match", `"33a64dF5"`)
+	req.Header.Add("Accept-Language", "en-US")
+	req.Header.Add("Accept-Language", "fr-CA")
+	_, le := serveEntry(t, r, tout, req)
+	assert.Equal(t, []any{"01HQ3Z8KX7Ab"}, le["http.request.header.x-request-id"])
+	assert.Equal(t, []any{`"33a64dF5"`}, le["http.request.header.if-none-match"])
+	assert.Equal(t, []any{"en-US", "fr-CA"}, le["http.request.header.accept-language"])
+	assert.Equal(t, []any{`W/"0815AbC"`}, le["http.response.header.etag"])
+	assert.NotContains(t, le, "http.response.header.set-cookie")
+	assert.NotContains(t, le, "http.response.header.cache-control")
+	assert.NotContains(t, le, "http.response.header.x-missing")
+	assert.NotContains(t, tout.String(), "session=secret")
+}
@@ -0,0 +1,274 @@
+package logs
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// LoggerAttr is the key of the field that holds the name of the loggers returned by Named.
+const LoggerAttr = "logger"
+
+// inheritLevel is the value of a levelFilter that follows the level of the logging system.
+const inheritLevel = math.MaxInt32
+
+// levelFilter is a zerolog hook that discards the entries below its level. The level can be changed at any time,
+// without replacing the loggers that use the filter.
+type levelFilter struct {
+	level atomic.Int32
+}
+
+func (f *levelFilter) get() zerolog.Level {
+	l := f.level.Load()
+	if l == inheritLevel {
+		return baseLevel.get()
+	}
+	return zerolog.Level(l)
+}
+
+func (f *levelFilter) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level < f.get() {
+		e.Discard()
+	}
+}
+
+var (
+	levelMu      sync.Mutex
+	rootLogger   zerolog.Logger
+	initialLevel zerolog.Level
+	baseLevel    = &levelFilter{}
+	namedLevels  = make(map[string]*levelFilter)
+	reverts      = make(map[string]*levelRevert)
+)
+
+// levelRevert restores the level of a logger once the ttl of a change expires.
+type levelRevert struct {
+	timer *time.Timer
+	level int32
+	at    time.Time
+}
+
+// initLevels resets the levels of the logging system to level, cancelling the pending reverts.
+func initLevels(root zerolog.Logger, level zerolog.Level) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	for name, r := range reverts {
+		r.timer.Stop()
+		delete(reverts, name)
+	}
+	for _, f := range namedLevels {
+		f.level.Store(inheritLevel)
+	}
+
+	rootLogger = root
+	initialLevel = level
+	baseLevel.level.Store(int32(level))
+	updateGlobalLevel()
+}
+
+// updateGlobalLevel sets the zerolog global level to the lowest level in use, so the entries of a named logger
+// with a lower level than the logging system are not filtered out before reaching its filter.
+func updateGlobalLevel() {
+	lowest := baseLevel.get()
+	for _, f := range namedLevels {
+		if l := f.get(); l < lowest {
+			lowest = l
+		}
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// enabled reports whether the entries at level are written by the logger of the logging system.
+func enabled(level zerolog.Level) bool {
+	return level >= baseLevel.get()
+}
+
+// Named returns a logger for a package or a component, whose level can be changed on its own with SetLoggerLevel.
+// Its entries have a logger field with the name. Until its level is set, it follows the level of the logging system.
+func Named(name string) zerolog.Logger {
+	if !isInitialized {
+		panic("logs.Initialize() must be invoked before using the logging system")
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	f, ok := namedLevels[name]
+	if !ok {
+		f = &levelFilter{}
+		f.level.Store(inheritLevel)
+		namedLevels[name] = f
+	}
+	return rootLogger.With().Str(LoggerAttr, name).Logger().Hook(f)
+}
+
+// SetLevel changes the level of the logging system. If ttl is not zero, the level is reverted once it expires.
+func SetLevel(level zerolog.Level, ttl time.Duration) {
+	setLevel("", level, ttl, "api")
+}
+
+// SetLoggerLevel changes the level of the logger returned by Named for name. If ttl is not zero, the level is
+// reverted once it expires.
+func SetLoggerLevel(name string, level zerolog.Level, ttl time.Duration) {
+	setLevel(name, level, ttl, "api")
+}
+
+// LevelInfo is the level of a logger, and when it reverts, if a change with a ttl is pending.
+type LevelInfo struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Levels is the level of the logging system, and those of the named loggers whose level was set.
+type Levels struct {
+	LevelInfo
+	Loggers map[string]LevelInfo `json:"loggers,omitempty"`
+}
+
+// CurrentLevels returns the levels in effect.
+func CurrentLevels() Levels {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	levels := Levels{LevelInfo: levelInfo("", baseLevel)}
+	for name, f := range namedLevels {
+		if f.level.Load() == inheritLevel {
+			continue
+		}
+		if levels.Loggers == nil {
+			levels.Loggers = make(map[string]LevelInfo)
+		}
+		levels.Loggers[name] = levelInfo(name, f)
+	}
+	return levels
+}
+
+func levelInfo(name string, f *levelFilter) LevelInfo {
+	info := LevelInfo{Level: f.get().String()}
+	if r, ok := reverts[name]; ok {
+		at := r.at
+		info.RevertAt = &at
+	}
+	return info
+}
+
+// setLevel changes the level of the named logger, or of the logging system if name is empty, and writes an
+// audit entry. A change with a ttl reverts to the level in effect before the first change of a series,
+// so consecutive temporary changes don't make the temporary level permanent.
+func setLevel(name string, level zerolog.Level, ttl time.Duration, source string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	f := baseLevel
+	if len(name) > 0 {
+		var ok bool
+		if f, ok = namedLevels[name]; !ok {
+			f = &levelFilter{}
+			f.level.Store(inheritLevel)
+			namedLevels[name] = f
+		}
+	}
+
+	previous := f.get()
+	r, pending := reverts[name]
+	if pending {
+		r.timer.Stop()
+		delete(reverts, name)
+	}
+	if ttl > 0 {
+		revertTo := f.level.Load()
+		if pending {
+			revertTo = r.level
+		}
+		reverts[name] = &levelRevert{
+			timer: time.AfterFunc(ttl, func() { revertLevel(name, revertTo) }),
+			level: revertTo,
+			at:    time.Now().Add(ttl),
+		}
+	}
+
+	f.level.Store(int32(level))
+	updateGlobalLevel()
+	auditLevel(name, previous, level, ttl, source)
+}
+
+func revertLevel(name string, level int32) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	f := baseLevel
+	if len(name) > 0 {
+		f = namedLevels[name]
+	}
+	previous := f.get()
+	delete(reverts, name)
+	f.level.Store(level)
+	updateGlobalLevel()
+	auditLevel(name, previous, f.get(), 0, "ttl")
+}
+
+// auditLevel writes an entry for every level change, whatever the level.
+func auditLevel(name string, previous, level zerolog.Level, ttl time.Duration, source string) {
+	e := rootLogger.Log().
+		Str("level.previous", previous.String()).
+		Str("level.new", level.String()).
+		Str("source", source)
+	if len(name) > 0 {
+		e = e.Str(LoggerAttr, name)
+	}
+	if ttl > 0 {
+		e = e.Str("ttl", ttl.String())
+	}
+	e.Msg("log level changed")
+}
+
+// levelRequest is the body of PUT requests to LevelHandler.
+type levelRequest struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger"`
+	TTL    string `json:"ttl"`
+}
+
+// LevelHandler returns a gin handler that reports the levels in effect on GET, and changes a level on PUT.
+// The body of a PUT request is a JSON object with the level, and optionally the name of a logger returned by
+// Named and a ttl, e.g., {"level":"debug","logger":"db","ttl":"10m"}. Register it on both methods:
+//
+//	r.GET("/loglevel", logs.LevelHandler())
+//	r.PUT("/loglevel", logs.LevelHandler())
+func LevelHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPut {
+			c.JSON(http.StatusOK, CurrentLevels())
+			return
+		}
+
+		var req levelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		level, err := zerolog.ParseLevel(req.Level)
+		if err != nil || len(req.Level) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid level %q", req.Level)})
+			return
+		}
+
+		var ttl time.Duration
+		if len(req.TTL) > 0 {
+			if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid ttl %q", req.TTL)})
+				return
+			}
+		}
+
+		setLevel(req.Logger, level, ttl, "http")
+		c.JSON(http.StatusOK, CurrentLevels())
+	}
+}
@@ -0,0 +1,118 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+func TestSetLevel(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	logs.Debug(context.Background(), "hidden", nil)
+	assert.Empty(t, tout.String())
+
+	logs.SetLevel(zerolog.DebugLevel, 0)
+	assert.Contains(t, tout.String(), `"level.previous":"info","level.new":"debug","source":"api"`)
+	tout.Reset()
+
+	logs.Debug(context.Background(), "shown", nil)
+	assert.Contains(t, tout.String(), "shown")
+	assert.Equal(t, "debug", logs.CurrentLevels().Level)
+
+	// the level passed to Initialize takes over again.
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+	assert.Equal(t, "info", logs.CurrentLevels().Level)
+}
+
+func TestSetLevel_TTL(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	logs.SetLevel(zerolog.DebugLevel, time.Hour)
+	logs.SetLevel(zerolog.TraceLevel, 50*time.Millisecond)
+	levels := logs.CurrentLevels()
+	assert.Equal(t, "trace", levels.Level)
+	require.NotNil(t, levels.RevertAt)
+
+	// consecutive temporary changes revert to the level in effect before the first one.
+	assert.Eventually(t, func() bool { return logs.CurrentLevels().Level == "info" }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, logs.CurrentLevels().RevertAt)
+	assert.Contains(t, tout.String(), `"level.previous":"trace","level.new":"info","source":"ttl"`)
+}
+
+func TestNamed(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+	db := logs.Named("db")
+	cache := logs.Named("cache")
+
+	logs.SetLoggerLevel("db", zerolog.DebugLevel, 0)
+	tout.Reset()
+
+	db.Debug().Msg("db debug")
+	cache.Debug().Msg("cache debug")
+	logs.Debug(context.Background(), "root debug", nil)
+	out := tout.String()
+	assert.Contains(t, out, `"logger":"db"`)
+	assert.Contains(t, out, "db debug")
+	assert.NotContains(t, out, "cache debug")
+	assert.NotContains(t, out, "root debug")
+
+	// a named logger whose level was not set follows the logging system.
+	logs.SetLevel(zerolog.DebugLevel, 0)
+	tout.Reset()
+	cache.Debug().Msg("cache debug")
+	assert.Contains(t, tout.String(), "cache debug")
+
+	assert.Equal(t, map[string]logs.LevelInfo{"db": {Level: "debug"}}, logs.CurrentLevels().Loggers)
+}
+
+func TestLevelHandler(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/loglevel", logs.LevelHandler())
+	r.PUT("/loglevel", logs.LevelHandler())
+
+	serve := func(method, body string) (int, logs.Levels) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/loglevel", strings.NewReader(body)))
+		var levels logs.Levels
+		_ = json.Unmarshal(w.Body.Bytes(), &levels)
+		return w.Code, levels
+	}
+
+	code, levels := serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "info", levels.Level)
+
+	code, levels = serve(http.MethodPut, `{"level":"debug","ttl":"10m"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "debug", levels.Level)
+	assert.NotNil(t, levels.RevertAt)
+	assert.Contains(t, tout.String(), `"source":"http","ttl":"10m0s"`)
+
+	code, levels = serve(http.MethodPut, `{"level":"warn","logger":"db"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warn", levels.Loggers["db"].Level)
+
+	for _, body := range []string{`{"level":"loud"}`, `{}`, `{"level":"debug","ttl":"soon"}`, `not json`} {
+		code, _ = serve(http.MethodPut, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+}
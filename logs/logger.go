@@ -30,8 +30,19 @@ const (
 var (
 	logger        zerolog.Logger
 	isInitialized bool
+	cfg           config
 )
 
+// Option configures optional behavior of the logging system.
+type Option func(*config)
+
+type config struct {
+	spanEvents     bool
+	spanEventLevel zerolog.Level
+	correlation    Correlation
+	otlp           otlpConfig
+}
+
 // Logger returns a pointer to the logger that is
 // used by the logging system.
 func Logger() *zerolog.Logger {
@@ -40,16 +51,29 @@ func Logger() *zerolog.Logger {
 
 // Initialize initializes the logging system.
 // It returns a logger that can be used to log messages, though it is not required.
-func Initialize(level zerolog.Level, ver, apiName, buildDate, commitHash, env string, writer io.Writer) {
+func Initialize(level zerolog.Level, ver, apiName, buildDate, commitHash, env string, writer io.Writer, opts ...Option) {
 	if writer == nil {
 		panic("nil writer passed to logger")
 	}
 
-	zerolog.SetGlobalLevel(level)
+	cfg = config{correlation: DatadogCorrelation()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	accessSkipped.Store(0)
+	accessSampled.Store(0)
+
 	zerolog.TimeFieldFormat = time.RFC3339Nano
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
 
-	logger = zerolog.New(writer).
+	startOTLP(cfg.otlp, writer, ver, apiName, buildDate, commitHash, env)
+	out := writer
+	if otlp != nil {
+		out = &otlpWriter{export: otlp}
+	}
+
+	root := zerolog.New(out).
 		With().
 		Timestamp().
 		Str("service", apiName).
@@ -59,23 +83,62 @@ func Initialize(level zerolog.Level, ver, apiName, buildDate, commitHash, env st
 		Str("env", env).
 		Logger()
 
+	// the level is applied by a hook, so it can be changed at runtime; see SetLevel.
+	initLevels(root, level)
+	logger = root.Hook(baseLevel)
+
 	isInitialized = true
 }
 
+// MiddlewareOption configures optional behavior of the logging middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	headers         headerRules
+	responseHeaders []string
+	query           QueryPolicy
+	body            *BodyCapture
+	access          *accessLog
+}
+
 // GinLoggingMiddleware logs the incoming request and starts the trace.
-func GinLoggingMiddleware() gin.HandlerFunc {
+func GinLoggingMiddleware(opts ...MiddlewareOption) gin.HandlerFunc {
 	if !isInitialized {
 		panic("logs.Initialize() must be invoked before using the logging middleware")
 	}
 
+	mCfg := middlewareConfig{headers: defaultHeaderRules, query: DefaultQueryPolicy()}
+	for _, opt := range opts {
+		opt(&mCfg)
+	}
+
 	return func(ctx *gin.Context) {
+		var bodies *bodyRecorder
+		if mCfg.body != nil {
+			bodies = mCfg.body.start(ctx)
+		}
+
+		// the request logger carries the request fields, so entries logged with logs.FromContext,
+		// or the helper funcs, during the request have them too. It keeps the fields added with With
+		// by the middlewares that run before this one.
+		reqPath := mCfg.query.Path(ctx.Request.URL.Path, ctx.FullPath())
+		reqLogger := storedLogger(ctx.Request.Context()).With().
+			Str(HttpMethod, ctx.Request.Method).
+			Str(HttpPath, reqPath).
+			Logger()
+		ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), ctxLoggerKey{}, &reqLogger))
+
 		s := time.Now()
 		ctx.Next()
 		e := time.Since(s)
 		status := ctx.Writer.Status()
+		failed := status > 499 || ctx.Errors.Last() != nil
+		if !failed && mCfg.access != nil && !mCfg.access.keep(ctx, e) {
+			return
+		}
+
+		// the method and path are written by the request logger.
 		args := map[string]any{
-			HttpMethod:     ctx.Request.Method,
-			HttpPath:       ctx.Request.URL.Path,
 			HttpRemoteAddr: ctx.Request.RemoteAddr,
 			HttpStatus:     status,
 			HttpLatency:    e.String(),
@@ -91,37 +154,32 @@ func GinLoggingMiddleware() gin.HandlerFunc {
 		args["http.request.host"] = ctx.Request.Host
 
 		if rQuery := ctx.Request.URL.RawQuery; len(rQuery) > 0 {
-			args[QueryString] = rQuery
+			args[QueryString] = mCfg.query.Query(rQuery)
 		}
 
-		hd := ParseHeaders(ctx.Request.Header)
+		hd := mCfg.headers.apply(ctx.Request.Header, RequestHeaderPrefix)
 		args = mergeMaps(args, hd)
+		if len(mCfg.responseHeaders) > 0 {
+			args = mergeMaps(args, mCfg.headers.apply(selectHeaders(ctx.Writer.Header(), mCfg.responseHeaders), ResponseHeaderPrefix))
+		}
 		ua := ParseUserAgent(ctx.Request.UserAgent())
 		args = mergeMaps(args, ua)
-		tId, ok := ctx.Get("trace_id")
-
-		if !ok {
-			tId = noTid
-		}
-
-		sId, ok := ctx.Get("span_id")
-		if !ok {
-			sId = noSid
+		args = mergeMaps(args, cfg.correlation.fields(ginSpanContext(ctx)))
+		if bodies != nil {
+			args = mergeMaps(args, bodies.fields(ctx, failed))
 		}
 
-		args[TraceIDAttr] = tId
-		args[SpanIDAttr] = sId
-
-		if status > 499 || ctx.Errors.Last() != nil {
+		reqLog := withSpan(storedLogger(ctx.Request.Context()), ginSpanContext(ctx))
+		if failed {
 			errs := strings.Join(ctx.Errors.Errors(), ";")
-			logger.Error().
+			reqLog.Error().
 				Fields(args).
 				Err(errors.New(errs)).
 				Msg("request failed")
 			return
 		}
 
-		logger.Info().
+		reqLog.Info().
 			Fields(args).
 			Msg("request successful")
 	}
@@ -138,13 +196,10 @@ func mergeMaps(m1 map[string]any, m2 map[string]any) map[string]any {
 	return merged
 }
 
-// ParseHeaders parses the headers and returns a map of attributes.
+// ParseHeaders parses the request headers and returns a map of attributes, keyed http.request.header.<name>.
+// The DefaultHeaderPolicy is applied, so headers that carry credentials are left out.
 func ParseHeaders(headers map[string][]string) (args map[string]any) {
-	args = make(map[string]any)
-	for k, v := range headers {
-		args[strings.ToLower("http."+k)] = strings.ToLower(strings.Join(v, ", "))
-	}
-	return
+	return defaultHeaderRules.apply(headers, RequestHeaderPrefix)
 }
 
 const (
@@ -161,8 +216,6 @@ const (
 	BrowserEdge             = "edge"
 	BrowserTrident          = "Trident"
 	QueryString             = "http.query"
-	noTid                   = "00000000000000000000000000000000"
-	noSid                   = "0000000000000000"
 )
 
 // ParseUserAgent parses the user agent string and returns a map of attributes.
@@ -216,8 +269,9 @@ func ParseUserAgent(rawUserAgent string) (args map[string]any) {
 // The args are key value pairs and are optional.
 func Debug(ctx context.Context, message string, args map[string]any) {
 	tInf := traceInfo(ctx)
+	annotateSpan(ctx, zerolog.DebugLevel, nil, message, args)
 	args = mergeMaps(args, tInf)
-	logger.Debug().
+	ctxLogger(ctx).Debug().
 		Fields(args).
 		Msg(message)
 }
@@ -226,8 +280,9 @@ func Debug(ctx context.Context, message string, args map[string]any) {
 // The args are key value pairs and are optional.
 func Info(ctx context.Context, message string, args map[string]any) {
 	tInf := traceInfo(ctx)
+	annotateSpan(ctx, zerolog.InfoLevel, nil, message, args)
 	args = mergeMaps(args, tInf)
-	logger.Info().
+	ctxLogger(ctx).Info().
 		Fields(args).
 		Msg(message)
 }
@@ -236,8 +291,9 @@ func Info(ctx context.Context, message string, args map[string]any) {
 // The args are key value pairs and are optional.
 func Warn(ctx context.Context, message string, args map[string]any) {
 	tInf := traceInfo(ctx)
+	annotateSpan(ctx, zerolog.WarnLevel, nil, message, args)
 	args = mergeMaps(args, tInf)
-	logger.Warn().
+	ctxLogger(ctx).Warn().
 		Fields(args).
 		Msg(message)
 }
@@ -245,8 +301,9 @@ func Warn(ctx context.Context, message string, args map[string]any) {
 // Error logs an error message and adds the trace id and span id fount in the ctx.
 func Error(ctx context.Context, err error, message string, args map[string]any) {
 	tInf := traceInfo(ctx)
+	annotateSpan(ctx, zerolog.ErrorLevel, err, message, args)
 	args = mergeMaps(args, tInf)
-	logger.Error().
+	ctxLogger(ctx).Error().
 		Fields(args).
 		Err(err).
 		Msg(message)
@@ -255,29 +312,39 @@ func Error(ctx context.Context, err error, message string, args map[string]any)
 // Fatal logs a fatal message and adds the trace id and span id fount in the ctx.
 func Fatal(ctx context.Context, err error, message string, args map[string]any) {
 	tInf := traceInfo(ctx)
+	annotateSpan(ctx, zerolog.FatalLevel, err, message, args)
 	args = mergeMaps(args, tInf)
-	logger.Fatal().
+	ctxLogger(ctx).Fatal().
 		Fields(args).
 		Err(err).
 		Msg(message)
 }
 
-// traceInfo returns the trace id and span id found in the ctx.
-func traceInfo(ctx context.Context) (tMap map[string]any) {
+// traceInfo returns the trace id and span id found in the ctx, formatted by the correlation profile.
+func traceInfo(ctx context.Context) map[string]any {
 	if !isInitialized {
 		panic("log.Initialize() must be invoked before using the logging system")
 	}
 
-	tMap = make(map[string]any, 2)
-	span := trace.SpanFromContext(ctx)
-	spanCtx := span.SpanContext()
-	if !spanCtx.TraceID().IsValid() {
-		tMap[TraceIDAttr] = noTid
-		tMap[SpanIDAttr] = noSid
-		return
+	return cfg.correlation.fields(trace.SpanContextFromContext(requestContext(ctx)))
+}
+
+// ginSpanContext returns the span context of the request. It falls back to the ids set by handlers
+// that don't put their span in the request context.
+func ginSpanContext(ctx *gin.Context) trace.SpanContext {
+	sc := trace.SpanContextFromContext(ctx.Request.Context())
+	if sc.IsValid() {
+		return sc
 	}
 
-	tMap[TraceIDAttr] = spanCtx.TraceID().String()
-	tMap[SpanIDAttr] = spanCtx.SpanID().String()
-	return
+	var scc trace.SpanContextConfig
+	if v, ok := ctx.Get("trace_id"); ok {
+		s, _ := v.(string)
+		scc.TraceID, _ = trace.TraceIDFromHex(s)
+	}
+	if v, ok := ctx.Get("span_id"); ok {
+		s, _ := v.(string)
+		scc.SpanID, _ = trace.SpanIDFromHex(s)
+	}
+	return trace.NewSpanContext(scc)
 }
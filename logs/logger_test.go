@@ -3,6 +3,7 @@ package logs_test
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"github.com/twistingmercury/monitoring/traces"
@@ -14,12 +15,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"os/exec"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/twistingmercury/monitoring/logs"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -28,11 +31,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	rBody = "hello world"
-	noTid = "00000000000000000000000000000000"
-	noSid = "0000000000000000"
-)
+const rBody = "hello world"
 
 var tracer trace.Tracer
 
@@ -95,7 +94,11 @@ func TestGinLoggingMiddleware(t *testing.T) {
 	tr := gin.New()
 
 	tr.Use(logs.GinLoggingMiddleware())
-	tr.GET("/test", testHandler)
+	var traceID, spanID string
+	tr.GET("/test", func(c *gin.Context) {
+		testHandler(c)
+		traceID, spanID = c.GetString("trace_id"), c.GetString("span_id")
+	})
 	req := newTestRequest(testUserAgents[0].ua)
 	w := httptest.NewRecorder()
 	tr.ServeHTTP(w, req)
@@ -114,11 +117,45 @@ func TestGinLoggingMiddleware(t *testing.T) {
 		t.Errorf("failed to unmarshal log entry: %v", err)
 	}
 
-	tid := le[logs.TraceIDAttr]
-	sid := le[logs.SpanIDAttr]
+	tid, _ := trace.TraceIDFromHex(traceID)
+	sid, _ := trace.SpanIDFromHex(spanID)
+	require.True(t, tid.IsValid() && sid.IsValid(), "the handler did not create a span")
+
+	// the default correlation is Datadog's: the lower 64 bits of the ids, in decimal.
+	assert.Equal(t, datadogTraceID(tid), le[logs.TraceIDAttr])
+	assert.Equal(t, datadogSpanID(sid), le[logs.SpanIDAttr])
+}
+
+func datadogTraceID(tid trace.TraceID) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(tid[8:]), 10)
+}
+
+func datadogSpanID(sid trace.SpanID) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(sid[:]), 10)
+}
+
+// newLoggingRouter initializes the logging system and returns a router that uses the logging middleware with opts,
+// and the buffer the entries are written to. Tests add their routes.
+func newLoggingRouter(t *testing.T, opts ...logs.MiddlewareOption) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logs.GinLoggingMiddleware(opts...))
+	return r, tout
+}
+
+// serveEntry serves req and returns the response and the access log entry written to tout.
+func serveEntry(t *testing.T, r *gin.Engine, tout *bytes.Buffer, req *http.Request) (w *httptest.ResponseRecorder, le map[string]any) {
+	t.Helper()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 
-	assert.NotEqual(t, tid, noTid, "trace id is empty")
-	assert.NotEqual(t, sid, noSid, "span id is empty")
+	le = make(map[string]any)
+	require.NoError(t, json.Unmarshal(tout.Bytes(), &le))
+	return w, le
 }
 
 func newTestRequest(ua string) (req *http.Request) {
@@ -228,8 +265,8 @@ func TestGinLoggingMiddleware_no_tracing(t *testing.T) {
 		t.Errorf("failed to unmarshal log entry: %v", err)
 	}
 
-	assert.Equal(t, le[logs.TraceIDAttr], noTid, "trace id should be empty")
-	assert.Equal(t, le[logs.SpanIDAttr], noSid, "span id  should be empty")
+	assert.Equal(t, le[logs.TraceIDAttr], "0", "trace id should be empty")
+	assert.Equal(t, le[logs.SpanIDAttr], "0", "span id  should be empty")
 }
 
 func TestInitPanicRecovers(t *testing.T) {
@@ -346,3 +383,56 @@ func TestLogger(t *testing.T) {
 	}
 	assert.Equal(t, zerolog.InfoLevel.String(), le[logs.LogLevel])
 }
+
+func TestGinLoggingMiddleware_with_tracing_middleware_default_correlation(t *testing.T) {
+	shutdown, err := traces.Initialize(traces.NewNoopExporter(), "0.0.1", "logs_test", "now", "456789", "local")
+	assert.NoError(t, err)
+	defer func() { _ = shutdown(context.Background()) }()
+
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+	gin.SetMode(gin.ReleaseMode)
+	tr := gin.New()
+
+	var sc trace.SpanContext
+	tr.Use(logs.GinLoggingMiddleware(), traces.GinTracingMiddleware())
+	tr.GET("/test", func(c *gin.Context) {
+		sc = trace.SpanContextFromContext(c.Request.Context())
+		c.String(http.StatusOK, rBody)
+	})
+	tr.ServeHTTP(httptest.NewRecorder(), newTestRequest(testUserAgents[0].ua))
+
+	le := make(map[string]any)
+	require.NoError(t, json.Unmarshal(tout.Bytes(), &le))
+	require.True(t, sc.IsValid())
+	assert.Equal(t, datadogTraceID(sc.TraceID()), le[logs.TraceIDAttr])
+	assert.Equal(t, datadogSpanID(sc.SpanID()), le[logs.SpanIDAttr])
+	assert.NotContains(t, le, "trace_id")
+	assert.NotContains(t, le, "span_id")
+}
+
+func TestGinLoggingMiddleware_with_tracing_middleware(t *testing.T) {
+	shutdown, err := traces.Initialize(traces.NewNoopExporter(), "0.0.1", "logs_test", "now", "456789", "local")
+	assert.NoError(t, err)
+	defer func() { _ = shutdown(context.Background()) }()
+
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout, logs.WithCorrelation(logs.OTelCorrelation()))
+	gin.SetMode(gin.ReleaseMode)
+	tr := gin.New()
+
+	var sc trace.SpanContext
+	tr.Use(logs.GinLoggingMiddleware(), traces.GinTracingMiddleware())
+	tr.GET("/test", func(c *gin.Context) {
+		sc = trace.SpanContextFromContext(c.Request.Context())
+		c.String(http.StatusOK, rBody)
+	})
+	w := httptest.NewRecorder()
+	tr.ServeHTTP(w, newTestRequest(testUserAgents[0].ua))
+
+	le := make(map[string]any)
+	assert.NoError(t, json.Unmarshal(tout.Bytes(), &le))
+	assert.Equal(t, sc.TraceID().String(), le["trace_id"])
+	assert.Equal(t, sc.SpanID().String(), le["span_id"])
+	assert.Equal(t, "01", le["trace_flags"])
+}
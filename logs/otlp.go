@@ -0,0 +1,293 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/twistingmercury/monitoring/internal/identity"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ScopeName is the instrumentation scope of the log records exported over OTLP.
+	ScopeName = "github.com/twistingmercury/monitoring/logs"
+
+	// ExceptionMessageAttr and ExceptionStacktraceAttr hold the error and the stack of an entry in the
+	// exported log records.
+	ExceptionMessageAttr    = "exception.message"
+	ExceptionStacktraceAttr = "exception.stacktrace"
+)
+
+// otlpFlushTimeout bounds the flush of the pending records before a fatal entry exits the process.
+const otlpFlushTimeout = 5 * time.Second
+
+// otlpConfig holds the options of the OTLP export.
+type otlpConfig struct {
+	exporter  sdklog.Exporter
+	batchOpts []sdklog.BatchProcessorOption
+	resource  *resource.Resource
+}
+
+// otlpExport is the state of the OTLP export while it is enabled.
+type otlpExport struct {
+	provider *sdklog.LoggerProvider
+	logger   otellog.Logger
+	// out is the writer passed to Initialize.
+	out io.Writer
+}
+
+var otlp *otlpExport
+
+// WithOTLP exports every entry written by the logging system as an OpenTelemetry log record, in addition to
+// writing it to the writer passed to Initialize. The records are batched and sent by exporter; use Shutdown
+// to flush them before the process exits. The batching is configured with the sdklog options, e.g.,
+// sdklog.WithExportInterval and sdklog.WithMaxQueueSize.
+func WithOTLP(exporter sdklog.Exporter, opts ...sdklog.BatchProcessorOption) Option {
+	return func(c *config) {
+		c.otlp.exporter = exporter
+		c.otlp.batchOpts = opts
+	}
+}
+
+// WithResource sets the resource of the exported log records. By default, the resource has the same service
+// attributes traces.Initialize sets on the spans.
+func WithResource(res *resource.Resource) Option {
+	return func(c *config) {
+		c.otlp.resource = res
+	}
+}
+
+// NewOTLPExporter creates a new OTLP HTTP log exporter. The endpoint is the host and port of the collector or
+// agent, e.g., "localhost:4318". TLS, headers, compression, retries and timeouts are configured with the
+// otlploghttp options, e.g., otlploghttp.WithInsecure, otlploghttp.WithTLSClientConfig,
+// otlploghttp.WithHeaders, otlploghttp.WithCompression, otlploghttp.WithRetry and otlploghttp.WithTimeout.
+func NewOTLPExporter(ctx context.Context, endpoint string, opts ...otlploghttp.Option) (exporter sdklog.Exporter, err error) {
+	opts = append(opts, otlploghttp.WithEndpoint(endpoint))
+	return otlploghttp.New(ctx, opts...)
+}
+
+// Shutdown flushes the pending log records and stops the OTLP export. It is a no-op if WithOTLP was not used.
+func Shutdown(ctx context.Context) error {
+	if otlp == nil {
+		return nil
+	}
+	return otlp.provider.Shutdown(ctx)
+}
+
+// startOTLP starts the OTLP export, stopping the one of a previous call to Initialize.
+func startOTLP(c otlpConfig, out io.Writer, ver, apiName, buildDate, commitHash, env string) {
+	stopOTLP()
+	if c.exporter == nil {
+		return
+	}
+
+	res := c.resource
+	if res == nil {
+		var err error
+		if res, err = newResource(ver, apiName, buildDate, commitHash, env); err != nil {
+			panic("failed to create the resource of the log records: " + err.Error())
+		}
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(c.exporter, c.batchOpts...)))
+	otlp = &otlpExport{
+		provider: provider,
+		logger:   provider.Logger(ScopeName),
+		out:      out,
+	}
+}
+
+func stopOTLP() {
+	if otlp == nil {
+		return
+	}
+	_ = otlp.provider.Shutdown(context.Background())
+	otlp = nil
+}
+
+// newResource creates the resource of the log records, with the service attributes traces.Initialize uses.
+func newResource(ver, apiName, buildDate, commitHash, env string) (*resource.Resource, error) {
+	svc := identity.Service{Name: apiName, Version: ver, BuildDate: buildDate, CommitHash: commitHash, Env: env}
+	return svc.Resource(nil)
+}
+
+// withSpan returns a copy of l whose exported records carry the span context sc. The written entries are
+// not changed.
+func withSpan(l *zerolog.Logger, sc trace.SpanContext) *zerolog.Logger {
+	if otlp == nil || !sc.IsValid() {
+		return l
+	}
+	o := l.Output(&otlpWriter{export: otlp, sc: sc})
+	return &o
+}
+
+// otlpWriter writes the entries to the writer passed to Initialize, and emits them as log records.
+type otlpWriter struct {
+	export *otlpExport
+	sc     trace.SpanContext
+}
+
+var _ zerolog.LevelWriter = (*otlpWriter)(nil)
+
+func (w *otlpWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *otlpWriter) WriteLevel(level zerolog.Level, p []byte) (n int, err error) {
+	if lw, ok := w.export.out.(zerolog.LevelWriter); ok {
+		n, err = lw.WriteLevel(level, p)
+	} else {
+		n, err = w.export.out.Write(p)
+	}
+
+	w.emit(level, p)
+	if level == zerolog.FatalLevel || level == zerolog.PanicLevel {
+		// the process exits once the entry is written.
+		ctx, cancel := context.WithTimeout(context.Background(), otlpFlushTimeout)
+		defer cancel()
+		_ = w.export.provider.ForceFlush(ctx)
+	}
+	return n, err
+}
+
+// emit converts the JSON entry to a log record. Entries that are not JSON objects are not exported.
+func (w *otlpWriter) emit(level zerolog.Level, p []byte) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	var fields map[string]any
+	if err := d.Decode(&fields); err != nil {
+		return
+	}
+
+	if level == zerolog.NoLevel {
+		if s, ok := fields[zerolog.LevelFieldName].(string); ok {
+			level, _ = zerolog.ParseLevel(s)
+		}
+	}
+
+	var r otellog.Record
+	r.SetObservedTimestamp(time.Now())
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if ts, err := time.Parse(zerolog.TimeFieldFormat, s); err == nil {
+			r.SetTimestamp(ts)
+		}
+	}
+	r.SetSeverity(severity(level))
+	if level != zerolog.NoLevel {
+		r.SetSeverityText(level.String())
+	}
+	if msg, ok := fields[zerolog.MessageFieldName].(string); ok {
+		r.SetBody(otellog.StringValue(msg))
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if !omitAttr(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otellog.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		name := k
+		switch k {
+		case zerolog.ErrorFieldName:
+			name = ExceptionMessageAttr
+		case zerolog.ErrorStackFieldName:
+			name = ExceptionStacktraceAttr
+			if _, ok := fields[k].(string); !ok {
+				b, _ := json.Marshal(fields[k])
+				fields[k] = string(b)
+			}
+		}
+		attrs = append(attrs, otellog.KeyValue{Key: name, Value: logValue(fields[k])})
+	}
+	r.AddAttributes(attrs...)
+
+	ctx := context.Background()
+	if w.sc.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, w.sc)
+	}
+	w.export.logger.Emit(ctx, r)
+}
+
+// omitAttr reports whether the field is not exported as an attribute: the level, time and message are
+// record fields, the service fields are on the resource and the trace ids are on the record.
+func omitAttr(k string) bool {
+	switch k {
+	case zerolog.LevelFieldName, zerolog.TimestampFieldName, zerolog.MessageFieldName,
+		"service", "version", "buildDate", "commitHash", "env":
+		return true
+	}
+	c := cfg.correlation
+	return k == c.TraceIDKey || k == c.SpanIDKey || k == c.TraceFlagsKey || k == c.SampledKey
+}
+
+// severity maps the zerolog levels to the OpenTelemetry severity numbers.
+func severity(level zerolog.Level) otellog.Severity {
+	switch level {
+	case zerolog.TraceLevel:
+		return otellog.SeverityTrace1
+	case zerolog.DebugLevel:
+		return otellog.SeverityDebug1
+	case zerolog.InfoLevel:
+		return otellog.SeverityInfo1
+	case zerolog.WarnLevel:
+		return otellog.SeverityWarn1
+	case zerolog.ErrorLevel:
+		return otellog.SeverityError1
+	case zerolog.FatalLevel:
+		return otellog.SeverityFatal1
+	case zerolog.PanicLevel:
+		return otellog.SeverityFatal4
+	default:
+		return otellog.SeverityUndefined
+	}
+}
+
+// logValue converts a decoded JSON value to a log record value.
+func logValue(v any) otellog.Value {
+	switch v := v.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return otellog.Int64Value(i)
+		}
+		f, _ := v.Float64()
+		return otellog.Float64Value(f)
+	case []any:
+		vals := make([]otellog.Value, 0, len(v))
+		for _, e := range v {
+			vals = append(vals, logValue(e))
+		}
+		return otellog.SliceValue(vals...)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvs := make([]otellog.KeyValue, 0, len(v))
+		for _, k := range keys {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: logValue(v[k])})
+		}
+		return otellog.MapValue(kvs...)
+	default:
+		return otellog.Value{}
+	}
+}
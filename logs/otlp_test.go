@@ -0,0 +1,172 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/trace"
+	collectorlogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeLogReceiver is an in-process OTLP HTTP receiver that records the log records it receives, and the
+// resource attributes of the last request.
+type fakeLogReceiver struct {
+	mu       sync.Mutex
+	records  []*logspb.LogRecord
+	resource map[string]string
+}
+
+func (r *fakeLogReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	raw, _ := io.ReadAll(req.Body)
+	ereq := &collectorlogs.ExportLogsServiceRequest{}
+	if req.URL.Path != "/v1/logs" || proto.Unmarshal(raw, ereq) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	for _, rl := range ereq.GetResourceLogs() {
+		r.resource = make(map[string]string)
+		for _, kv := range rl.GetResource().GetAttributes() {
+			r.resource[kv.GetKey()] = kv.GetValue().GetStringValue()
+		}
+		for _, sl := range rl.GetScopeLogs() {
+			r.records = append(r.records, sl.GetLogRecords()...)
+		}
+	}
+	r.mu.Unlock()
+
+	res, _ := proto.Marshal(&collectorlogs.ExportLogsServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(res)
+}
+
+func (r *fakeLogReceiver) logRecords() []*logspb.LogRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*logspb.LogRecord(nil), r.records...)
+}
+
+func attrs(r *logspb.LogRecord) map[string]*commonpb.AnyValue {
+	m := make(map[string]*commonpb.AnyValue)
+	for _, kv := range r.GetAttributes() {
+		m[kv.GetKey()] = kv.GetValue()
+	}
+	return m
+}
+
+func initOTLP(t *testing.T) (*fakeLogReceiver, *bytes.Buffer) {
+	t.Helper()
+	rcv := &fakeLogReceiver{}
+	svr := httptest.NewServer(rcv)
+	t.Cleanup(svr.Close)
+
+	exporter, err := logs.NewOTLPExporter(context.Background(), strings.TrimPrefix(svr.URL, "http://"), otlploghttp.WithInsecure())
+	require.NoError(t, err)
+
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithCorrelation(logs.OTelCorrelation()),
+		logs.WithOTLP(exporter))
+	t.Cleanup(func() { _ = logs.Shutdown(context.Background()) })
+	return rcv, tout
+}
+
+func TestWithOTLP(t *testing.T) {
+	rcv, tout := initOTLP(t)
+
+	tid, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	sid, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logs.Info(ctx, "hello", map[string]any{"count": 3, "ratio": 0.5, "tags": []string{"a", "b"}})
+	logs.Error(context.Background(), errors.New("boom"), "failed", nil)
+	require.NoError(t, logs.Shutdown(context.Background()))
+
+	// the entries are still written to the writer.
+	assert.Equal(t, 2, strings.Count(tout.String(), "\n"))
+
+	records := rcv.logRecords()
+	require.Len(t, records, 2)
+
+	info := records[0]
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, info.GetSeverityNumber())
+	assert.Equal(t, "info", info.GetSeverityText())
+	assert.Equal(t, "hello", info.GetBody().GetStringValue())
+	assert.NotZero(t, info.GetTimeUnixNano())
+	assert.Equal(t, tid[:], info.GetTraceId())
+	assert.Equal(t, sid[:], info.GetSpanId())
+
+	a := attrs(info)
+	assert.Equal(t, int64(3), a["count"].GetIntValue())
+	assert.Equal(t, 0.5, a["ratio"].GetDoubleValue())
+	assert.Len(t, a["tags"].GetArrayValue().GetValues(), 2)
+	// the service fields are on the resource, and the trace ids on the record.
+	for _, k := range []string{"level", "message", "time", "service", "version", "trace_id", "span_id", "trace_flags"} {
+		assert.NotContains(t, a, k)
+	}
+
+	failed := records[1]
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, failed.GetSeverityNumber())
+	assert.Equal(t, "boom", attrs(failed)[logs.ExceptionMessageAttr].GetStringValue())
+	assert.Empty(t, failed.GetTraceId())
+
+	assert.Equal(t, "logs_test", rcv.resource["service.name"])
+	assert.Equal(t, "0.0.1", rcv.resource["service.version"])
+	assert.Equal(t, "local", rcv.resource["env"])
+}
+
+func TestWithOTLP_Middleware(t *testing.T) {
+	rcv, _ := initOTLP(t)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+		c.Set("span_id", "00f067aa0ba902b7")
+	})
+	r.Use(logs.GinLoggingMiddleware())
+	r.GET("/person/:id", func(c *gin.Context) {
+		logs.FromContext(c.Request.Context()).Debug().Msg("loading")
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/person/1", nil))
+	require.NoError(t, logs.Shutdown(context.Background()))
+
+	records := rcv.logRecords()
+	require.Len(t, records, 2)
+	assert.Equal(t, "loading", records[0].GetBody().GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, records[0].GetSeverityNumber())
+	assert.Equal(t, http.MethodGet, attrs(records[0])[logs.HttpMethod].GetStringValue())
+
+	access := records[1]
+	assert.Equal(t, "request successful", access.GetBody().GetStringValue())
+	assert.Equal(t, int64(http.StatusOK), attrs(access)[logs.HttpStatus].GetIntValue())
+	tid, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, tid[:], access.GetTraceId())
+}
+
+func TestShutdown_WithoutOTLP(t *testing.T) {
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", &bytes.Buffer{})
+	assert.NoError(t, logs.Shutdown(context.Background()))
+}
@@ -0,0 +1,25 @@
+package logs
+
+import "github.com/twistingmercury/monitoring/internal/redact"
+
+// QueryPolicy controls how the query string and the path of the request are logged: query parameters can be
+// dropped or masked, by name or by pattern, and gin path parameters masked. The same policy can be passed to
+// traces.WithQueryPolicy, so logs and traces are redacted alike.
+type QueryPolicy = redact.QueryPolicy
+
+// DefaultQueryParams are the query parameters masked by DefaultQueryPolicy.
+var DefaultQueryParams = redact.DefaultQueryParams
+
+// DefaultQueryPolicy returns the policy used when none is supplied: parameters such as token, password, signature
+// and code, and the parameters whose names contain token, secret, passw, signature or credential, are masked.
+func DefaultQueryPolicy() QueryPolicy {
+	return redact.DefaultQueryPolicy()
+}
+
+// WithQueryPolicy sets the policy the logging middleware applies to the query string and the path of the request.
+// The default is DefaultQueryPolicy.
+func WithQueryPolicy(p QueryPolicy) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.query = p
+	}
+}
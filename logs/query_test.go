@@ -0,0 +1,39 @@
+package logs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+func serveWithQuery(t *testing.T, target string, opts ...logs.MiddlewareOption) (raw string, le map[string]any) {
+	t.Helper()
+	r, tout := newLoggingRouter(t, opts...)
+	r.GET("/users/:email", func(c *gin.Context) { c.Status(http.StatusOK) })
+	_, le = serveEntry(t, r, tout, httptest.NewRequest(http.MethodGet, target, nil))
+	return tout.String(), le
+}
+
+func TestGinLoggingMiddleware_DefaultQueryPolicy(t *testing.T) {
+	raw, le := serveWithQuery(t, "/users/jane@example.com?access_token=s3cr3t&page=2&X-Amz-Signature=f00ba4")
+
+	assert.NotContains(t, raw, "s3cr3t")
+	assert.NotContains(t, raw, "f00ba4")
+	assert.Equal(t, "access_token=****&page=2&X-Amz-Signature=****", le[logs.QueryString])
+	assert.Equal(t, "/users/jane@example.com", le[logs.HttpPath])
+}
+
+func TestGinLoggingMiddleware_QueryPolicy(t *testing.T) {
+	p := logs.DefaultQueryPolicy()
+	p.Drop = []string{"email"}
+	p.PathParams = []string{"email"}
+	raw, le := serveWithQuery(t, "/users/jane@example.com?email=jane%40example.com&page=2", logs.WithQueryPolicy(p))
+
+	assert.NotContains(t, raw, "jane")
+	assert.Equal(t, "page=2", le[logs.QueryString])
+	assert.Equal(t, "/users/****", le[logs.HttpPath])
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package logs
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// HandleLevelSignals changes the level of the logging system when the process receives a signal:
+// SIGUSR1 sets it to debug, and SIGUSR2 restores the level passed to Initialize. If ttl is not zero,
+// the debug level reverts once it expires. The returned func stops the handling of the signals.
+func HandleLevelSignals(ttl time.Duration) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				switch sig {
+				case syscall.SIGUSR1:
+					setLevel("", zerolog.DebugLevel, ttl, "signal")
+				case syscall.SIGUSR2:
+					levelMu.Lock()
+					level := initialLevel
+					levelMu.Unlock()
+					setLevel("", level, 0, "signal")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
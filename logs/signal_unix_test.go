@@ -0,0 +1,28 @@
+//go:build !windows
+
+package logs_test
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+)
+
+func TestHandleLevelSignals(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.WarnLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+	stop := logs.HandleLevelSignals(0)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return logs.CurrentLevels().Level == "debug" }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Eventually(t, func() bool { return logs.CurrentLevels().Level == "warn" }, time.Second, 10*time.Millisecond)
+}
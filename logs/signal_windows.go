@@ -0,0 +1,10 @@
+//go:build windows
+
+package logs
+
+import "time"
+
+// HandleLevelSignals is a no-op on Windows, which has no SIGUSR1 and SIGUSR2; use LevelHandler instead.
+func HandleLevelSignals(ttl time.Duration) (stop func()) {
+	return func() {}
+}
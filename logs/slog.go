@@ -0,0 +1,157 @@
+package logs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// SlogHandler is a slog.Handler that writes through the logger of the logging system, so entries logged with
+// log/slog have the same service fields, levels and trace correlation as the others. The trace id and span id
+// are taken from the context passed to the slog funcs, e.g., slog.InfoContext, and the fields added with With
+// are taken from the request logger found in it.
+type SlogHandler struct {
+	// fields holds the attributes added with WithAttrs, nested by group.
+	fields map[string]any
+	groups []string
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a slog.Handler that writes through the logger of the logging system.
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{fields: map[string]any{}}
+}
+
+// SetSlogDefault makes a SlogHandler the handler of the default slog logger, so libraries that log with
+// log/slog write through the logging system.
+func SetSlogDefault() {
+	slog.SetDefault(slog.New(NewSlogHandler()))
+}
+
+// Enabled reports whether entries at the level are written.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	zl := zerologLevel(level)
+	return zl >= zerolog.GlobalLevel() && enabled(zl)
+}
+
+// Handle writes the record.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := copyFields(h.fields)
+	group := groupOf(fields, h.groups)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(group, a)
+		return true
+	})
+	pruneGroups(fields)
+
+	ctxLogger(ctx).WithLevel(zerologLevel(r.Level)).
+		Fields(mergeMaps(fields, traceInfo(ctx))).
+		Msg(r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler whose entries have the attributes, in the current group.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := copyFields(h.fields)
+	group := groupOf(fields, h.groups)
+	for _, a := range attrs {
+		addAttr(group, a)
+	}
+	return &SlogHandler{fields: fields, groups: h.groups}
+}
+
+// WithGroup returns a handler whose subsequent attributes are nested in the group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	groups := append(append([]string(nil), h.groups...), name)
+	return &SlogHandler{fields: h.fields, groups: groups}
+}
+
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// addAttr adds the attribute to the fields, nesting groups as maps.
+func addAttr(fields map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		v := a.Value.Any()
+		// errors nested in groups would be marshaled as empty objects.
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[a.Key] = v
+		return
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+	group := fields
+	if len(a.Key) > 0 {
+		group = groupOf(fields, []string{a.Key})
+	}
+	for _, ga := range attrs {
+		addAttr(group, ga)
+	}
+}
+
+// groupOf returns the map of the nested group, creating it if needed.
+func groupOf(fields map[string]any, groups []string) map[string]any {
+	for _, g := range groups {
+		sub, ok := fields[g].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			fields[g] = sub
+		}
+		fields = sub
+	}
+	return fields
+}
+
+// pruneGroups removes the groups without attributes.
+func pruneGroups(fields map[string]any) {
+	for k, v := range fields {
+		if sub, ok := v.(map[string]any); ok {
+			pruneGroups(sub)
+			if len(sub) == 0 {
+				delete(fields, k)
+			}
+		}
+	}
+}
+
+// copyFields deep copies the nested groups, so handlers don't share them.
+func copyFields(fields map[string]any) map[string]any {
+	c := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if sub, ok := v.(map[string]any); ok {
+			v = copyFields(sub)
+		}
+		c[k] = v
+	}
+	return c
+}
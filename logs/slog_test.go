@@ -0,0 +1,98 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"github.com/twistingmercury/monitoring/traces"
+	"github.com/twistingmercury/monitoring/traces/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func parseLines(t *testing.T, tout *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(tout.String()), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		le := make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(line), &le))
+		entries = append(entries, le)
+	}
+	return entries
+}
+
+func TestSlogHandler(t *testing.T) {
+	tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.InfoLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	ctx, span, err := traces.Start(context.Background(), "work", trace.SpanKindInternal)
+	require.NoError(t, err)
+	defer span.End()
+	ctx = logs.With(ctx, map[string]any{"user.id": "u-42"})
+
+	l := slog.New(logs.NewSlogHandler()).With("component", "billing").WithGroup("req").With("id", 7)
+	l.DebugContext(ctx, "hidden")
+	l.InfoContext(ctx, "charged", slog.Group("card", "brand", "visa"), "amount", 12.5)
+	l.ErrorContext(ctx, "declined", "err", errors.New("insufficient funds"))
+
+	entries := parseLines(t, tout)
+	require.Len(t, entries, 2)
+
+	e := entries[0]
+	assert.Equal(t, "info", e["level"])
+	assert.Equal(t, "charged", e["message"])
+	assert.Equal(t, "logs_test", e["service"])
+	assert.Equal(t, "billing", e["component"])
+	assert.Equal(t, "u-42", e["user.id"])
+	assert.Equal(t, map[string]any{"id": float64(7), "amount": 12.5, "card": map[string]any{"brand": "visa"}}, e["req"])
+	assert.NotEqual(t, "0", e[logs.TraceIDAttr])
+	assert.NotEqual(t, "0", e[logs.SpanIDAttr])
+
+	assert.Equal(t, "error", entries[1]["level"])
+	assert.Equal(t, "insufficient funds", entries[1]["req"].(map[string]any)["err"])
+}
+
+func TestSetSlogDefault(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+	logs.SetSlogDefault()
+
+	slog.Debug("from slog", "k", "v")
+	entries := parseLines(t, tout)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "debug", entries[0]["level"])
+	assert.Equal(t, "v", entries[0]["k"])
+	assert.Equal(t, "0", entries[0][logs.TraceIDAttr])
+}
+
+func TestSlogHandler_EmptyGroups(t *testing.T) {
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	l := slog.New(logs.NewSlogHandler())
+	l.WithGroup("empty").Info("no attrs")
+	l.Info("empty group", slog.Group("g"), slog.Attr{})
+	l.WithGroup("a").With("x", 1).WithGroup("b").Info("nested")
+
+	entries := parseLines(t, tout)
+	require.Len(t, entries, 3)
+	assert.NotContains(t, entries[0], "empty")
+	assert.NotContains(t, entries[1], "g")
+	assert.NotContains(t, entries[1], "")
+	assert.Equal(t, map[string]any{"x": float64(1)}, entries[2]["a"])
+}
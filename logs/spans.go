@@ -0,0 +1,107 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogSeverityAttr is the span event attribute that holds the level of the log entry.
+const LogSeverityAttr = "log.severity"
+
+// WithSpanEvents mirrors the entries written by the helper funcs at or above minLevel into the active span
+// found in the ctx: the message and args are added as a span event. For Error and Fatal, the error is
+// also recorded on the span, with a stack trace, and the span status is set to error.
+// zerolog.WarnLevel is a sensible value for minLevel.
+func WithSpanEvents(minLevel zerolog.Level) Option {
+	return func(c *config) {
+		c.spanEvents = true
+		c.spanEventLevel = minLevel
+	}
+}
+
+// annotateSpan adds the log entry to the span found in the ctx, if span events are enabled.
+func annotateSpan(ctx context.Context, level zerolog.Level, err error, message string, args map[string]any) {
+	if !cfg.spanEvents || level < cfg.spanEventLevel || ctx == nil {
+		return
+	}
+
+	// entries that are not written are not mirrored either.
+	if !enabled(level) {
+		return
+	}
+
+	span := trace.SpanFromContext(requestContext(ctx))
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := append(toAttributes(args), attribute.String(LogSeverityAttr, level.String()))
+	span.AddEvent(message, trace.WithAttributes(attrs...))
+
+	if level < zerolog.ErrorLevel {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err, trace.WithStackTrace(true))
+	}
+	span.SetStatus(otelCodes.Error, message)
+}
+
+// toAttributes converts the args of a log entry to span attributes, sorted by key.
+func toAttributes(args map[string]any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(args)+1)
+	for k, v := range args {
+		attrs = append(attrs, toAttribute(k, v))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
+
+func toAttribute(k string, v any) attribute.KeyValue {
+	switch val := v.(type) {
+	case string:
+		return attribute.String(k, val)
+	case bool:
+		return attribute.Bool(k, val)
+	case int:
+		return attribute.Int(k, val)
+	case int32:
+		return attribute.Int(k, int(val))
+	case int64:
+		return attribute.Int64(k, val)
+	case uint32:
+		return attribute.Int64(k, int64(val))
+	case float32:
+		return attribute.Float64(k, float64(val))
+	case float64:
+		return attribute.Float64(k, val)
+	case []string:
+		return attribute.StringSlice(k, val)
+	case []int:
+		return attribute.IntSlice(k, val)
+	case []int64:
+		return attribute.Int64Slice(k, val)
+	case []float64:
+		return attribute.Float64Slice(k, val)
+	case []bool:
+		return attribute.BoolSlice(k, val)
+	case time.Duration:
+		return attribute.String(k, val.String())
+	case time.Time:
+		return attribute.String(k, val.Format(time.RFC3339Nano))
+	case error:
+		return attribute.String(k, val.Error())
+	case fmt.Stringer:
+		return attribute.String(k, val.String())
+	default:
+		return attribute.String(k, fmt.Sprintf("%v", val))
+	}
+}
@@ -0,0 +1,153 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"github.com/twistingmercury/monitoring/traces"
+	"github.com/twistingmercury/monitoring/traces/tracetest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanEvents(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithSpanEvents(zerolog.WarnLevel))
+
+	ctx, span, err := traces.Start(context.Background(), "work", trace.SpanKindInternal)
+	require.NoError(t, err)
+	logs.Info(ctx, "below threshold", map[string]any{"arg1": "value1"})
+	logs.Warn(ctx, "slow query", map[string]any{"table": "person", "rows": 12, "cached": false})
+	logs.Error(ctx, errors.New("boom"), "query failed", map[string]any{"table": "person"})
+	span.End()
+
+	s := rec.AssertSpan(t, "work",
+		tracetest.HasStatus(codes.Error),
+		tracetest.HasEvent("slow query",
+			attribute.String("table", "person"),
+			attribute.Int("rows", 12),
+			attribute.Bool("cached", false),
+			attribute.String(logs.LogSeverityAttr, "warn")),
+		tracetest.HasEvent("query failed", attribute.String(logs.LogSeverityAttr, "error")),
+		tracetest.HasEvent("exception", attribute.String("exception.message", "boom")))
+	assert.Equal(t, "query failed", s.Status.Description)
+
+	var names []string
+	for _, e := range s.Events {
+		names = append(names, e.Name)
+		if e.Name != "exception" {
+			continue
+		}
+		var hasStack bool
+		for _, kv := range e.Attributes {
+			hasStack = hasStack || (kv.Key == "exception.stacktrace" && len(kv.Value.AsString()) > 0)
+		}
+		assert.True(t, hasStack, "the recorded error should have a stack trace")
+	}
+	assert.NotContains(t, names, "below threshold")
+}
+
+func TestSpanEvents_InfoThreshold(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithSpanEvents(zerolog.InfoLevel))
+
+	ctx, span, _ := traces.Start(context.Background(), "work", trace.SpanKindInternal)
+	logs.Debug(ctx, "too verbose", nil)
+	logs.Info(ctx, "checkpoint", map[string]any{"step": int64(2)})
+	span.End()
+
+	s := rec.AssertSpan(t, "work",
+		tracetest.HasStatus(codes.Unset),
+		tracetest.HasEvent("checkpoint", attribute.Int64("step", 2)))
+	assert.Len(t, s.Events, 1)
+}
+
+func TestSpanEvents_ServerSpan(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithSpanEvents(zerolog.WarnLevel))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/person/:id", func(c *gin.Context) {
+		// the handler recovers from the error and still responds with a 200.
+		logs.Error(c.Request.Context(), errors.New("cache unavailable"), "cache lookup failed", nil)
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/person/1", nil))
+
+	s := rec.AssertSpan(t, "GET /person/:id",
+		tracetest.HasStatus(codes.Error),
+		tracetest.HasEvent("cache lookup failed", attribute.String(logs.LogSeverityAttr, "error")),
+		tracetest.HasEvent("exception", attribute.String("exception.message", "cache unavailable")))
+	assert.Equal(t, "cache lookup failed", s.Status.Description)
+}
+
+func TestSpanEvents_ServerSpanFailure(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithSpanEvents(zerolog.WarnLevel))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/person/:id", func(c *gin.Context) {
+		logs.Error(c.Request.Context(), errors.New("connection refused"), "database unavailable", nil)
+		c.Status(http.StatusServiceUnavailable)
+	})
+	r.GET("/order/:id", func(c *gin.Context) {
+		_ = c.Error(errors.New("order service timed out"))
+		c.Status(http.StatusBadGateway)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/person/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/order/1", nil))
+
+	s := rec.AssertSpan(t, "GET /person/:id", tracetest.HasStatus(codes.Error))
+	assert.Equal(t, "database unavailable", s.Status.Description, "the status set by logs.Error is kept")
+	s = rec.AssertSpan(t, "GET /order/:id", tracetest.HasStatus(codes.Error))
+	assert.Equal(t, "order service timed out", s.Status.Description)
+}
+
+func TestSpanEvents_Disabled(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "logs_test", "now", "456789", "local", tout)
+
+	ctx, span, _ := traces.Start(context.Background(), "work", trace.SpanKindInternal)
+	logs.Error(ctx, errors.New("boom"), "query failed", nil)
+	span.End()
+
+	s := rec.AssertSpan(t, "work", tracetest.HasStatus(codes.Unset))
+	assert.Empty(t, s.Events)
+}
+
+func TestSpanEvents_FilteredByLevel(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.ErrorLevel, "0.0.1", "logs_test", "now", "456789", "local", tout,
+		logs.WithSpanEvents(zerolog.WarnLevel))
+
+	ctx, span, _ := traces.Start(context.Background(), "work", trace.SpanKindInternal)
+	logs.Warn(ctx, "not written", nil)
+	span.End()
+
+	assert.Empty(t, tout.String())
+	assert.Empty(t, rec.AssertSpan(t, "work").Events)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import "sync"
+
+// labelLimiter folds the values of a label beyond its limit into OtherLabelValue.
+type labelLimiter struct {
+	max  int
+	mu   sync.Mutex
+	seen map[string]map[string]struct{}
+}
+
+func newLabelLimiter(max int) *labelLimiter {
+	return &labelLimiter{max: max, seen: make(map[string]map[string]struct{})}
+}
+
+// defaultLimits are the limits of the labels whose values are set by the clients, applied when no limit is set.
+var defaultLimits = map[string]int{LabelHost: DefaultHostCardinalityLimit}
+
+// value returns v if it is one of the first max values seen for the label, and OtherLabelValue otherwise.
+func (l *labelLimiter) value(label, v string) string {
+	if l == nil {
+		return v
+	}
+	max := l.max
+	if max <= 0 {
+		max = defaultLimits[label]
+	}
+	if max <= 0 {
+		return v
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	values, ok := l.seen[label]
+	if !ok {
+		values = make(map[string]struct{})
+		l.seen[label] = values
+	}
+	if _, ok := values[v]; ok {
+		return v
+	}
+	if len(values) >= max {
+		return OtherLabelValue
+	}
+	values[v] = struct{}{}
+	return v
+}
@@ -1,4 +1,43 @@
 package metrics
 
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
 var Reset = reset
 var GetMetricValue = CounterValue
+
+// LabelValues returns the distinct values of the label across the series of the Collector, sorted.
+func LabelValues(col prometheus.Collector, label string) (values []string) {
+	seen := make(map[string]bool)
+	collect(col, func(m *dto.Metric) {
+		for _, lp := range m.GetLabel() {
+			if lp.GetName() == label && !seen[lp.GetValue()] {
+				seen[lp.GetValue()] = true
+				values = append(values, lp.GetValue())
+			}
+		}
+	})
+	sort.Strings(values)
+	return
+}
+
+// SeriesCount returns the number of series of the Collector.
+func SeriesCount(col prometheus.Collector) (n int) {
+	collect(col, func(*dto.Metric) { n++ })
+	return
+}
+
+// Histogram returns the histogram of the last series of the Collector.
+func Histogram(col prometheus.Collector) (h *dto.Histogram) {
+	collect(col, func(m *dto.Metric) { h = m.GetHistogram() })
+	return
+}
+
+// Gather returns the metrics exposed for scraping.
+func Gather() ([]*dto.MetricFamily, error) {
+	return registry.Gather()
+}
@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	LabelPath        = "path"
+	LabelHttpMethod  = "http_method"
+	LabelStatusCode  = "status_code"
+	LabelStatusClass = "status_class"
+	LabelHost        = "host"
+	LabelHandler     = "handler"
+)
+
+// LabelPolicy selects the labels of the HTTP metrics. The zero value is the default policy: path, http_method
+// and status_code.
+type LabelPolicy struct {
+	// StatusClass replaces the status_code label with status_class, whose values are 2xx, 3xx, and so on, which
+	// divides the number of series by the number of distinct codes of each class.
+	StatusClass bool
+	// Host adds the host label, the host the request was sent to. The Host header is set by the client, so the
+	// label has at most DefaultHostCardinalityLimit values, or the limit of WithCardinalityLimit if it is set.
+	Host bool
+	// Handler adds the handler label, the name of the gin handler of the route.
+	Handler bool
+	// Custom adds labels whose values are extracted from the gin.Context once the request has been handled,
+	// e.g., the tier of the tenant set by an authentication middleware. They are not on the concurrent calls gauge.
+	Custom []CustomLabel
+	// Drop removes the labels, e.g., http_method, from all the HTTP metrics.
+	Drop []string
+}
+
+// CustomLabel is a label whose value is extracted from the gin.Context.
+type CustomLabel struct {
+	Name  string
+	Value func(c *gin.Context) string
+}
+
+// WithLabelPolicy sets the labels of the HTTP metrics.
+func WithLabelPolicy(p LabelPolicy) Option {
+	return func(c *config) {
+		c.labels = p
+	}
+}
+
+// labelSet is the label names of the HTTP metrics, resolved from the LabelPolicy.
+type labelSet struct {
+	// start is the labels known when the request starts, and the labels of the concurrent calls gauge.
+	start []string
+	// status is the status label of the totals and duration: status_code or status_class, or empty if dropped.
+	status string
+	// sizeStatus is the status label of the size histograms, always the class, or empty if dropped.
+	sizeStatus string
+	custom     []CustomLabel
+}
+
+func newLabelSet(p LabelPolicy) labelSet {
+	drop := make(map[string]bool, len(p.Drop))
+	for _, name := range p.Drop {
+		drop[name] = true
+	}
+	keep := func(name string) bool { return !drop[name] }
+
+	var ls labelSet
+	for _, name := range []string{LabelPath, LabelHttpMethod} {
+		if keep(name) {
+			ls.start = append(ls.start, name)
+		}
+	}
+	if p.Host && keep(LabelHost) {
+		ls.start = append(ls.start, LabelHost)
+	}
+	if p.Handler && keep(LabelHandler) {
+		ls.start = append(ls.start, LabelHandler)
+	}
+
+	status := LabelStatusCode
+	if p.StatusClass {
+		status = LabelStatusClass
+	}
+	if keep(status) {
+		ls.status = status
+	}
+	if keep(LabelStatusClass) {
+		ls.sizeStatus = LabelStatusClass
+	}
+
+	for _, cl := range p.Custom {
+		if keep(cl.Name) {
+			ls.custom = append(ls.custom, cl)
+		}
+	}
+	return ls
+}
+
+// api returns the labels of the totals and duration metrics.
+func (ls labelSet) api() []string {
+	return ls.with(ls.status)
+}
+
+// size returns the labels of the size histograms.
+func (ls labelSet) size() []string {
+	return ls.with(ls.sizeStatus)
+}
+
+func (ls labelSet) with(status string) []string {
+	names := append([]string(nil), ls.start...)
+	if len(status) > 0 {
+		names = append(names, status)
+	}
+	for _, cl := range ls.custom {
+		names = append(names, cl.Name)
+	}
+	return names
+}
+
+// startValues returns the values of the start labels.
+func (ls labelSet) startValues(c *gin.Context, unmatchedPath string) []string {
+	values := make([]string, 0, len(ls.start))
+	for _, name := range ls.start {
+		var v string
+		switch name {
+		case LabelPath:
+			if v = c.FullPath(); len(v) == 0 {
+				v = unmatchedPath
+			}
+		case LabelHttpMethod:
+			v = methodOf(c.Request.Method)
+		case LabelHost:
+			v = c.Request.Host
+		case LabelHandler:
+			v = c.HandlerName()
+		}
+		values = append(values, limiter.value(name, v))
+	}
+	return values
+}
+
+// methodOf returns the http_method label value of the method: the method itself if it is a standard HTTP
+// method, otherwise OtherMethod, so arbitrary methods sent by clients don't create series.
+func methodOf(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return OtherMethod
+	}
+}
+
+// endValues returns the values of the labels of the totals, duration and size metrics, known once the request
+// has been handled.
+func (ls labelSet) endValues(c *gin.Context, start []string) (api, size []string) {
+	status := c.Writer.Status()
+	custom := make([]string, 0, len(ls.custom))
+	for _, cl := range ls.custom {
+		custom = append(custom, limiter.value(cl.Name, cl.Value(c)))
+	}
+
+	api = append([]string(nil), start...)
+	switch ls.status {
+	case LabelStatusCode:
+		api = append(api, limiter.value(LabelStatusCode, strconv.Itoa(status)))
+	case LabelStatusClass:
+		api = append(api, limiter.value(LabelStatusClass, statusClassOf(status)))
+	}
+	api = append(api, custom...)
+
+	size = append([]string(nil), start...)
+	if len(ls.sizeStatus) > 0 {
+		size = append(size, limiter.value(LabelStatusClass, statusClassOf(status)))
+	}
+	size = append(size, custom...)
+	return api, size
+}
@@ -34,6 +34,12 @@ var (
 	totalCalls      *prometheus.CounterVec
 	concurrentCalls *prometheus.GaugeVec
 	callDuration    *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+	cfg             config
+	limiter         *labelLimiter
+	labels          = newLabelSet(LabelPolicy{})
+	rec             recorder
 )
 
 func IsInitialized() bool {
@@ -44,21 +50,47 @@ func Port() string {
 	return mPort
 }
 
-// ConcurrentCalls returns the number of concurrent calls to the API.
+// ConcurrentCalls returns the number of concurrent calls to the API. The collectors of the HTTP metrics
+// are nil with the OpenTelemetry backend; see WithOTel.
 func ConcurrentCalls() prometheus.Collector {
+	if concurrentCalls == nil {
+		return nil
+	}
 	return concurrentCalls
 }
 
 // TotalCalls returns the total number of calls to the API.
 func TotalCalls() prometheus.Collector {
+	if totalCalls == nil {
+		return nil
+	}
 	return totalCalls
 }
 
 // CallDuration returns the duration of calls to the API.
 func CallDuration() prometheus.Collector {
+	if callDuration == nil {
+		return nil
+	}
 	return callDuration
 }
 
+// RequestSize returns the size of the request bodies.
+func RequestSize() prometheus.Collector {
+	if requestSize == nil {
+		return nil
+	}
+	return requestSize
+}
+
+// ResponseSize returns the size of the response bodies.
+func ResponseSize() prometheus.Collector {
+	if responseSize == nil {
+		return nil
+	}
+	return responseSize
+}
+
 // MetricNames returns the names of the metrics associated with the Collector.
 func MetricNames() []string {
 	return mNames
@@ -67,8 +99,7 @@ func MetricNames() []string {
 // CounterValue returns the value of the metric associated with the Collector
 // This is to facilitate unit testing of the package.
 func CounterValue(col prometheus.Collector) (v float64, err error) {
-	//goland:noinspection GoVetCopyLock
-	collect(col, func(m dto.Metric) {
+	collect(col, func(m *dto.Metric) {
 		if h := m.GetHistogram(); h != nil {
 			v = float64(h.GetSampleCount())
 		} else {
@@ -80,17 +111,15 @@ func CounterValue(col prometheus.Collector) (v float64, err error) {
 
 // collect calls the function for each metric associated with the Collector.
 // This is to facilitate unit testing of the package.
-//
-//goland:noinspection ALL
-func collect(col prometheus.Collector, do func(dto.Metric)) {
+func collect(col prometheus.Collector, do func(*dto.Metric)) {
 	c := make(chan prometheus.Metric)
 	go func(c chan prometheus.Metric) {
 		col.Collect(c)
 		close(c)
 	}(c)
 	for x := range c { // eg range across distinct label vector values
-		m := dto.Metric{}
-		_ = x.Write(&m)
+		m := &dto.Metric{}
+		_ = x.Write(m)
 		do(m)
 	}
 }
@@ -104,19 +133,23 @@ func reset() {
 	isInit = false
 	svr = nil
 
-	if registry != nil {
+	if registry != nil && !cfg.otel.enabled {
 		registry.Unregister(concurrentCalls)
 		registry.Unregister(totalCalls)
 		registry.Unregister(callDuration)
+		registry.Unregister(requestSize)
+		registry.Unregister(responseSize)
 	}
+	stopOTel()
 
 	pubOnce = &sync.Once{}
 	initOnce = &sync.Once{}
+	labels = newLabelSet(LabelPolicy{})
 }
 
 // Initialize initializes metrics system so it can TestRegisterFuncs metrics.
 // This must be called before any metrics are registered.
-func Initialize(port string, namespace string) {
+func Initialize(port string, namespace string, opts ...Option) {
 	initOnce.Do(func() {
 		if len(port) == 0 {
 			panic("port for metrics must be specified")
@@ -132,6 +165,9 @@ func Initialize(port string, namespace string) {
 
 		mPort = port
 		nspace = namespace
+		cfg = newConfig(opts...)
+		limiter = newLabelLimiter(cfg.maxLabelValues)
+		labels = newLabelSet(cfg.labels)
 
 		idx := strings.LastIndex(os.Args[0], `/`)
 		n := strings.TrimLeft(os.Args[0][idx+1:], `_`)
@@ -143,39 +179,61 @@ func Initialize(port string, namespace string) {
 	})
 }
 
+// MetricApiLabels returns the labels of the total calls and call duration metrics, selected by the LabelPolicy.
 func MetricApiLabels() []string {
-	return []string{"path", "http_method", "status_code"}
+	return labels.api()
 }
 
+const (
+	concurrentCallsHelp = "the count of concurrent calls to the APIs, grouped by API name, path, and response code"
+	totalCallsHelp      = "The count of all call to the API, grouped by API name, path, and response code"
+	callDurationHelp    = "The duration in seconds of calls to the API, grouped by API name, path, and response code"
+	requestSizeHelp     = "The size in bytes of the request bodies, grouped by API name, path, and response status class"
+	responseSizeHelp    = "The size in bytes of the response bodies, grouped by API name, path, and response status class"
+)
+
 // newApiMetrics creates a new metrics object 'p' is the path of the API and 'm' is the HTTP method of the API.
 func newApiMetrics() {
 	registry = prometheus.NewRegistry()
 
-	concurentCallsName := normalize(fmt.Sprintf("%s_concurrent_calls", apiName))
+	mNames = []string{
+		normalize(fmt.Sprintf("%s_concurrent_calls", apiName)),
+		normalize(fmt.Sprintf("%s_total_calls", apiName)),
+		normalize(fmt.Sprintf("%s_call_duration_seconds", apiName)),
+		normalize(fmt.Sprintf("%s_http_request_size_bytes", apiName)),
+		normalize(fmt.Sprintf("%s_http_response_size_bytes", apiName)),
+	}
+
+	if cfg.otel.enabled {
+		concurrentCalls, totalCalls, callDuration, requestSize, responseSize = nil, nil, nil, nil, nil
+		rec = newOTelRecorder()
+		log.Debug().Msg("newApiMetrics invoked")
+		return
+	}
+
 	concurrentCalls = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: Namespace(),
-		Name:      concurentCallsName,
-		Help:      "the count of concurrent calls to the APIs, grouped by API name, path, and response code"},
-		[]string{"path", "http_method"})
+		Name:      mNames[0],
+		Help:      concurrentCallsHelp},
+		labels.start)
 
-	totalCallsName := normalize(fmt.Sprintf("%s_total_calls", apiName))
 	totalCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: Namespace(),
-		Name:      totalCallsName,
-		Help:      "The count of all call to the API, grouped by API name, path, and response code"},
+		Name:      mNames[1],
+		Help:      totalCallsHelp},
 		MetricApiLabels())
 
-	callDurationName := normalize(fmt.Sprintf("%s_call_duration", apiName))
-	callDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Namespace: Namespace(),
-		Name:      callDurationName,
-		Help:      "The duration in milliseconds calls to the API, grouped by API name, path, and response code",
-		Buckets:   prometheus.ExponentialBuckets(0.1, 1.5, 5)},
+	callDuration = prometheus.NewHistogramVec(cfg.histogramOpts(mNames[2], callDurationHelp, cfg.durationBuckets),
 		MetricApiLabels())
 
-	mNames = []string{concurentCallsName, totalCallsName, callDurationName}
+	requestSize = prometheus.NewHistogramVec(cfg.histogramOpts(mNames[3], requestSizeHelp, cfg.requestSizeBuckets),
+		labels.size())
 
-	registry.MustRegister(concurrentCalls, totalCalls, callDuration)
+	responseSize = prometheus.NewHistogramVec(cfg.histogramOpts(mNames[4], responseSizeHelp, cfg.responseSizeBuckets),
+		labels.size())
+
+	registry.MustRegister(concurrentCalls, totalCalls, callDuration, requestSize, responseSize)
+	rec = promRecorder{}
 	log.Debug().Msg("newApiMetrics invoked")
 }
 
@@ -218,28 +276,36 @@ func RegisterCustomMetrics(cMetrics ...prometheus.Collector) {
 }
 
 // GinMetricsMiddleWare is a middleware function that captures quantitative metrics for the request.
+// The path label is the route template, e.g., "/person/:id", rather than the raw path, and the methods that are
+// not standard HTTP methods are labeled OtherMethod, so the number of series of the default labels is bounded by
+// the number of routes and methods. The labels are selected with WithLabelPolicy.
 func GinMetricsMiddleWare() gin.HandlerFunc {
 	if !isInit {
 		panic(initErrMsg)
 	}
 	return func(c *gin.Context) {
-		path := c.Request.URL.Path
-		method := c.Request.Method
-		var statusCode string
+		start := labels.startValues(c, cfg.unmatchedPath)
+		body := countBody(c.Request)
+		ctx := c.Request.Context()
 		var duration float64
 
-		concurrentCalls.WithLabelValues(path, method).Inc()
+		rec.begin(ctx, start)
 
 		defer func() {
-			concurrentCalls.WithLabelValues(path, method).Dec()
-			callDuration.WithLabelValues(path, method, statusCode).Observe(duration)
-			totalCalls.WithLabelValues(path, method, statusCode).Inc()
+			api, size := labels.endValues(c, start)
+			rec.end(ctx, requestMetrics{
+				start:        start,
+				api:          api,
+				size:         size,
+				duration:     duration,
+				requestSize:  body.size(),
+				responseSize: int64(max(c.Writer.Size(), 0)),
+			})
 		}()
 
-		start := time.Now()
+		begin := time.Now()
 		c.Next()
-		duration = float64(time.Since(start).Milliseconds())
-		statusCode = strconv.Itoa(c.Writer.Status())
+		duration = time.Since(begin).Seconds()
 	}
 }
 
@@ -1,12 +1,18 @@
 package metrics_test
 
 import (
+	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/twistingmercury/monitoring/metrics"
 )
@@ -66,12 +72,269 @@ func TestGinMiddleware(t *testing.T) {
 	assert.Greater(t, dVal, float64(0))
 }
 
+func newMetricsRouter(routes ...string) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(metrics.GinMetricsMiddleWare())
+	for _, route := range routes {
+		r.GET(route, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	return r
+}
+
+func hammer(r *gin.Engine, n int, path func(i int) string) {
+	for i := 0; i < n; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path(i), nil))
+	}
+}
+
+func TestGinMiddleware_RouteTemplate(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test")
+	r := newMetricsRouter("/person/:id")
+
+	hammer(r, 500, func(int) string { return fmt.Sprintf("/person/%d", rand.Int63()) })
+	hammer(r, 500, func(int) string { return fmt.Sprintf("/%x/%x", rand.Int63(), rand.Int63()) })
+	// scanners also send arbitrary methods.
+	for i := 0; i < 200; i++ {
+		method := fmt.Sprintf("M%X", rand.Int63())
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, fmt.Sprintf("/%x", rand.Int63()), nil))
+	}
+
+	assert.Equal(t, []string{"/person/:id", metrics.DefaultUnmatchedPath}, metrics.LabelValues(metrics.TotalCalls(), "path"))
+	assert.Equal(t, []string{http.MethodGet, metrics.OtherMethod}, metrics.LabelValues(metrics.TotalCalls(), "http_method"))
+	assert.Equal(t, 3, metrics.SeriesCount(metrics.TotalCalls()))
+	assert.Equal(t, 3, metrics.SeriesCount(metrics.CallDuration()))
+	assert.Equal(t, 3, metrics.SeriesCount(metrics.ConcurrentCalls()))
+}
+
+func TestGinMiddleware_UnmatchedPath(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithUnmatchedPath("not_found"))
+	r := newMetricsRouter("/person/:id")
+
+	hammer(r, 10, func(i int) string { return fmt.Sprintf("/unknown/%d", i) })
+	assert.Equal(t, []string{"not_found"}, metrics.LabelValues(metrics.TotalCalls(), "path"))
+}
+
+func TestGinMiddleware_CardinalityLimit(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithCardinalityLimit(5))
+
+	routes := make([]string, 20)
+	for i := range routes {
+		routes[i] = fmt.Sprintf("/route%d/:id", i)
+	}
+	r := newMetricsRouter(routes...)
+
+	hammer(r, 2000, func(i int) string { return fmt.Sprintf("/route%d/%d", i%len(routes), rand.Int63()) })
+
+	paths := metrics.LabelValues(metrics.TotalCalls(), "path")
+	assert.Len(t, paths, 6)
+	assert.Contains(t, paths, metrics.OtherLabelValue)
+	assert.LessOrEqual(t, metrics.SeriesCount(metrics.TotalCalls()), 6)
+
+	// the requests beyond the limit are still counted.
+	other, err := metrics.GetMetricValue(metrics.TotalCalls().(*prometheus.CounterVec).WithLabelValues(metrics.OtherLabelValue, http.MethodGet, "200"))
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1500), other)
+}
+
+func TestGinMiddleware_DurationSeconds(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test")
+	r := newMetricsRouter()
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	hammer(r, 1, func(int) string { return "/slow" })
+
+	h := metrics.Histogram(metrics.CallDuration())
+	assert.Greater(t, h.GetSampleSum(), 0.02)
+	assert.Less(t, h.GetSampleSum(), 1.0)
+
+	bounds := make([]float64, 0, len(h.GetBucket()))
+	for _, b := range h.GetBucket() {
+		bounds = append(bounds, b.GetUpperBound())
+	}
+	assert.Equal(t, prometheus.DefBuckets, bounds)
+	// the request lands in the 25ms bucket, not only in +Inf.
+	assert.Equal(t, uint64(0), h.GetBucket()[1].GetCumulativeCount())
+	assert.Equal(t, uint64(1), h.GetBucket()[len(bounds)-1].GetCumulativeCount())
+}
+
+func TestGinMiddleware_DurationBuckets(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithDurationBuckets(0.1, 0.5, 1))
+	hammer(newMetricsRouter("/good"), 1, func(int) string { return "/good" })
+
+	h := metrics.Histogram(metrics.CallDuration())
+	assert.Len(t, h.GetBucket(), 3)
+	assert.Equal(t, 0.5, h.GetBucket()[1].GetUpperBound())
+	assert.Equal(t, uint64(1), h.GetBucket()[0].GetCumulativeCount())
+	assert.Zero(t, h.GetSchema())
+}
+
+func TestGinMiddleware_NativeHistograms(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithNativeHistograms(1.1))
+	hammer(newMetricsRouter("/good"), 1, func(int) string { return "/good" })
+
+	h := metrics.Histogram(metrics.CallDuration())
+	assert.Equal(t, int32(3), h.GetSchema())
+	assert.NotEmpty(t, h.GetPositiveSpan())
+	assert.NotEmpty(t, h.GetBucket(), "the classic buckets are still exposed")
+}
+
+func sizeHistogram(col prometheus.Collector, path, method, class string) *dto.Histogram {
+	return metrics.Histogram(col.(*prometheus.HistogramVec).WithLabelValues(path, method, class).(prometheus.Histogram))
+}
+
+func TestGinMiddleware_Sizes(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test")
+	r := newMetricsRouter()
+	r.POST("/person/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if len(body) == 0 {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusCreated, strings.Repeat("x", 500))
+	})
+
+	post := func(body io.Reader) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/person/1", body))
+	}
+	post(strings.NewReader(strings.Repeat("a", 1234)))
+	// a body of unknown length is measured by the bytes read.
+	post(io.MultiReader(strings.NewReader(strings.Repeat("b", 300))))
+	post(nil)
+
+	req := sizeHistogram(metrics.RequestSize(), "/person/:id", http.MethodPost, "2xx")
+	assert.Equal(t, uint64(2), req.GetSampleCount())
+	assert.Equal(t, float64(1534), req.GetSampleSum())
+
+	res := sizeHistogram(metrics.ResponseSize(), "/person/:id", http.MethodPost, "2xx")
+	assert.Equal(t, float64(1000), res.GetSampleSum())
+	assert.Equal(t, uint64(0), res.GetBucket()[0].GetCumulativeCount())
+	assert.Equal(t, uint64(2), res.GetBucket()[1].GetCumulativeCount())
+
+	failed := sizeHistogram(metrics.ResponseSize(), "/person/:id", http.MethodPost, "4xx")
+	assert.Equal(t, uint64(1), failed.GetSampleCount())
+	assert.Zero(t, failed.GetSampleSum())
+}
+
+func TestGinMiddleware_SizeBuckets(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithRequestSizeBuckets(10, 20), metrics.WithResponseSizeBuckets(1))
+	hammer(newMetricsRouter("/good"), 1, func(int) string { return "/good" })
+
+	assert.Len(t, sizeHistogram(metrics.RequestSize(), "/good", http.MethodGet, "2xx").GetBucket(), 2)
+	assert.Len(t, sizeHistogram(metrics.ResponseSize(), "/good", http.MethodGet, "2xx").GetBucket(), 1)
+}
+
+func TestGinMiddleware_LabelPolicy(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithLabelPolicy(metrics.LabelPolicy{
+		StatusClass: true,
+		Host:        true,
+		Handler:     true,
+		Custom: []metrics.CustomLabel{
+			{Name: "tier", Value: func(c *gin.Context) string { return c.GetString("tier") }},
+		},
+		Drop: []string{metrics.LabelHttpMethod},
+	}))
+	assert.Equal(t, []string{"path", "host", "handler", "status_class", "tier"}, metrics.MetricApiLabels())
+
+	r := newMetricsRouter()
+	r.GET("/person/:id", func(c *gin.Context) {
+		c.Set("tier", "gold")
+		c.Status(http.StatusNotFound)
+	})
+	hammer(r, 3, func(i int) string { return fmt.Sprintf("/person/%d", i) })
+
+	assert.Equal(t, []string{"4xx"}, metrics.LabelValues(metrics.TotalCalls(), "status_class"))
+	assert.Equal(t, []string{"gold"}, metrics.LabelValues(metrics.TotalCalls(), "tier"))
+	assert.Equal(t, []string{"example.com"}, metrics.LabelValues(metrics.TotalCalls(), "host"))
+	assert.Len(t, metrics.LabelValues(metrics.TotalCalls(), "handler"), 1)
+	assert.Empty(t, metrics.LabelValues(metrics.TotalCalls(), "http_method"))
+	assert.Empty(t, metrics.LabelValues(metrics.ConcurrentCalls(), "tier"), "custom labels are not on the gauge")
+	assert.Equal(t, []string{"gold"}, metrics.LabelValues(metrics.ResponseSize(), "tier"))
+
+	count, err := metrics.GetMetricValue(metrics.TotalCalls())
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), count)
+}
+
+func TestGinMiddleware_DropStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    metrics.LabelPolicy
+		apiLabels []string
+		sizeClass bool
+	}{
+		{
+			name:      "status_code",
+			policy:    metrics.LabelPolicy{Drop: []string{metrics.LabelStatusCode}},
+			apiLabels: []string{"path", "http_method"},
+			sizeClass: true,
+		},
+		{
+			name:      "status_code with status class",
+			policy:    metrics.LabelPolicy{StatusClass: true, Drop: []string{metrics.LabelStatusCode}},
+			apiLabels: []string{"path", "http_method", "status_class"},
+			sizeClass: true,
+		},
+		{
+			name:      "status_class",
+			policy:    metrics.LabelPolicy{Drop: []string{metrics.LabelStatusClass}},
+			apiLabels: []string{"path", "http_method", "status_code"},
+			sizeClass: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer metrics.Reset()
+			metrics.Initialize("1024", "test", metrics.WithLabelPolicy(tt.policy))
+			hammer(newMetricsRouter("/good"), 1, func(int) string { return "/good" })
+
+			assert.Equal(t, tt.apiLabels, metrics.MetricApiLabels())
+			if tt.sizeClass {
+				assert.Equal(t, []string{"2xx"}, metrics.LabelValues(metrics.RequestSize(), "status_class"))
+			} else {
+				assert.Empty(t, metrics.LabelValues(metrics.RequestSize(), "status_class"))
+			}
+			assert.Equal(t, 1, metrics.SeriesCount(metrics.TotalCalls()))
+		})
+	}
+}
+
+func TestGinMiddleware_HostLimit(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithLabelPolicy(metrics.LabelPolicy{Host: true}))
+	r := newMetricsRouter("/good")
+
+	for i := 0; i < 2*metrics.DefaultHostCardinalityLimit; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/good", nil)
+		req.Host = fmt.Sprintf("%x.example.com", rand.Int63())
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	hosts := metrics.LabelValues(metrics.TotalCalls(), "host")
+	assert.Len(t, hosts, metrics.DefaultHostCardinalityLimit+1)
+	assert.Contains(t, hosts, metrics.OtherLabelValue)
+}
+
 func TestGinMiddlewareNames(t *testing.T) {
 	defer metrics.Reset()
 	expected := []string{
 		"metrics_test_concurrent_calls",
 		"metrics_test_total_calls",
-		"metrics_test_call_duration"}
+		"metrics_test_call_duration_seconds",
+		"metrics_test_http_request_size_bytes",
+		"metrics_test_http_response_size_bytes"}
 	metrics.Initialize("1024", "test")
 	metrics.Publish()
 	assert.Equal(t, expected, metrics.MetricNames())
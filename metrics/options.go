@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultNativeHistogramMaxBuckets is the maximum number of buckets of the native histograms, beyond which
+	// the resolution is reduced.
+	DefaultNativeHistogramMaxBuckets = 160
+
+	// DefaultUnmatchedPath is the path label value of the requests that don't match a gin route, e.g., 404s.
+	DefaultUnmatchedPath = "unmatched"
+	// OtherLabelValue is the value that replaces the label values beyond the cardinality limit.
+	OtherLabelValue = "__other__"
+	// DefaultHostCardinalityLimit is the number of distinct values of the host label when WithCardinalityLimit
+	// is not set; the others are recorded as OtherLabelValue.
+	DefaultHostCardinalityLimit = 100
+	// OtherMethod is the http_method label value of the requests whose method is not a standard HTTP method.
+	OtherMethod = "_OTHER"
+)
+
+// DefaultSizeBuckets are the default buckets of the request and response size histograms: 100B to 10MB.
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+// Option configures optional behavior of the metrics system.
+type Option func(*config)
+
+type config struct {
+	unmatchedPath       string
+	maxLabelValues      int
+	durationBuckets     []float64
+	requestSizeBuckets  []float64
+	responseSizeBuckets []float64
+	nativeFactor        float64
+	labels              LabelPolicy
+	otel                otelConfig
+}
+
+func newConfig(opts ...Option) config {
+	c := config{
+		unmatchedPath:       DefaultUnmatchedPath,
+		durationBuckets:     prometheus.DefBuckets,
+		requestSizeBuckets:  DefaultSizeBuckets,
+		responseSizeBuckets: DefaultSizeBuckets,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithUnmatchedPath sets the path label value of the requests that don't match a gin route, so scanners
+// probing random URLs don't create a series per URL. The default is DefaultUnmatchedPath.
+func WithUnmatchedPath(value string) Option {
+	return func(c *config) {
+		c.unmatchedPath = value
+	}
+}
+
+// WithCardinalityLimit caps the number of distinct values of each label of the HTTP metrics. Once a label has
+// max values, new values are recorded as OtherLabelValue. A max of 0, the default, means no limit, except for the
+// host label, which is capped at DefaultHostCardinalityLimit.
+func WithCardinalityLimit(max int) Option {
+	return func(c *config) {
+		c.maxLabelValues = max
+	}
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the buckets of the call duration histogram.
+// The default is prometheus.DefBuckets, from 5ms to 10s.
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.durationBuckets = buckets
+	}
+}
+
+// WithRequestSizeBuckets sets the upper bounds, in bytes, of the buckets of the request size histogram.
+// The default is DefaultSizeBuckets.
+func WithRequestSizeBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.requestSizeBuckets = buckets
+	}
+}
+
+// WithResponseSizeBuckets sets the upper bounds, in bytes, of the buckets of the response size histogram.
+// The default is DefaultSizeBuckets.
+func WithResponseSizeBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.responseSizeBuckets = buckets
+	}
+}
+
+// WithNativeHistograms makes the histograms also native (sparse) histograms, whose buckets grow by at most
+// bucketFactor, e.g., 1.1. Native histograms have a high resolution at a low cost, but are only scraped by
+// Prometheus servers that have them enabled; the classic buckets are still exposed for the others.
+func WithNativeHistograms(bucketFactor float64) Option {
+	return func(c *config) {
+		c.nativeFactor = bucketFactor
+	}
+}
+
+// histogramOpts returns the opts of a histogram of the HTTP metrics.
+func (c config) histogramOpts(name, help string, buckets []float64) prometheus.HistogramOpts {
+	opts := prometheus.HistogramOpts{
+		Namespace: Namespace(),
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}
+	if c.nativeFactor > 1 {
+		opts.NativeHistogramBucketFactor = c.nativeFactor
+		opts.NativeHistogramMaxBucketNumber = DefaultNativeHistogramMaxBuckets
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return opts
+}
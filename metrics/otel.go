@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/twistingmercury/monitoring/internal/identity"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// ScopeName is the instrumentation scope of the HTTP metrics recorded with the OpenTelemetry backend.
+const ScopeName = "github.com/twistingmercury/monitoring/metrics"
+
+const otelBackendErrMsg = "the OpenTelemetry backend must be enabled with WithOTel to use the meter"
+
+// otelConfig holds the options of the OpenTelemetry backend.
+type otelConfig struct {
+	enabled  bool
+	readers  []sdkmetric.Reader
+	otlp     []otlpReader
+	resource *resource.Resource
+	service  identity.Service
+}
+
+// otlpReader is a periodic reader to create once the options are known.
+type otlpReader struct {
+	exporter sdkmetric.Exporter
+	opts     []sdkmetric.PeriodicReaderOption
+}
+
+var provider *sdkmetric.MeterProvider
+
+// WithOTel records the HTTP metrics with the OpenTelemetry metrics SDK rather than the prometheus client. The
+// metrics keep their names and labels, and are still exposed for scraping by Publish; they can also be pushed
+// with WithOTLP. Use Meter to create custom instruments, the equivalent of RegisterCustomMetrics.
+func WithOTel() Option {
+	return func(c *config) {
+		c.otel.enabled = true
+	}
+}
+
+// WithReader adds a reader of the OpenTelemetry backend, e.g., a sdkmetric.NewManualReader for tests.
+// It enables the backend.
+func WithReader(reader sdkmetric.Reader) Option {
+	return func(c *config) {
+		c.otel.enabled = true
+		c.otel.readers = append(c.otel.readers, reader)
+	}
+}
+
+// WithOTLP pushes the metrics periodically with exporter, e.g., one created with NewOTLPExporter. The interval is
+// configured with the sdkmetric options, e.g., sdkmetric.WithInterval. It enables the OpenTelemetry backend.
+// With WithNativeHistograms, the histograms are pushed as exponential histograms.
+func WithOTLP(exporter sdkmetric.Exporter, opts ...sdkmetric.PeriodicReaderOption) Option {
+	return func(c *config) {
+		c.otel.enabled = true
+		c.otel.otlp = append(c.otel.otlp, otlpReader{exporter: exporter, opts: opts})
+	}
+}
+
+// WithService sets the resource of the OpenTelemetry backend to the service attributes traces.Initialize sets on
+// the spans, so metrics, traces and logs of the service can be correlated. By default, service.name is the name
+// of the executable.
+func WithService(ver, apiName, buildDate, commitHash, env string) Option {
+	return func(c *config) {
+		c.otel.service = identity.Service{Name: apiName, Version: ver, BuildDate: buildDate, CommitHash: commitHash, Env: env}
+	}
+}
+
+// WithResource sets the resource of the OpenTelemetry backend, e.g., the one of the traces provider.
+// It takes precedence over WithService.
+func WithResource(res *resource.Resource) Option {
+	return func(c *config) {
+		c.otel.resource = res
+	}
+}
+
+// NewOTLPExporter creates a new OTLP HTTP metric exporter. The endpoint is the host and port of the collector or
+// agent, e.g., "localhost:4318". TLS, headers, compression, retries and timeouts are configured with the
+// otlpmetrichttp options, e.g., otlpmetrichttp.WithInsecure, otlpmetrichttp.WithTLSClientConfig,
+// otlpmetrichttp.WithHeaders, otlpmetrichttp.WithCompression, otlpmetrichttp.WithRetry and otlpmetrichttp.WithTimeout.
+func NewOTLPExporter(ctx context.Context, endpoint string, opts ...otlpmetrichttp.Option) (exporter sdkmetric.Exporter, err error) {
+	opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint))
+	return otlpmetrichttp.New(ctx, opts...)
+}
+
+// MeterProvider returns the meter provider of the OpenTelemetry backend. This will panic if Initialize has not
+// been called first, or if the backend is not enabled.
+func MeterProvider() metric.MeterProvider {
+	if !isInit {
+		panic(initErrMsg)
+	}
+	if provider == nil {
+		panic(otelBackendErrMsg)
+	}
+	return provider
+}
+
+// Meter returns a meter of the OpenTelemetry backend, to create custom instruments that are exported with the
+// HTTP metrics. This will panic if Initialize has not been called first, or if the backend is not enabled.
+func Meter() metric.Meter {
+	return MeterProvider().Meter(ScopeName)
+}
+
+// Shutdown pushes the pending metrics and stops the OpenTelemetry backend. It is a no-op if the backend is
+// not enabled.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+func stopOTel() {
+	if provider == nil {
+		return
+	}
+	_ = provider.Shutdown(context.Background())
+	provider = nil
+}
+
+// newOTelProvider creates the meter provider, whose prometheus reader exposes the metrics in the registry.
+func newOTelProvider() (*sdkmetric.MeterProvider, error) {
+	res := cfg.otel.resource
+	if res == nil {
+		svc := cfg.otel.service
+		if len(svc.Name) == 0 {
+			svc.Name = apiName
+		}
+		var err error
+		if res, err = svc.Resource(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// the names are already those of the prometheus backend.
+	exporter, err := otelprom.New(
+		otelprom.WithRegisterer(registry),
+		otelprom.WithNamespace(Namespace()),
+		otelprom.WithoutCounterSuffixes(),
+		otelprom.WithoutUnits(),
+		otelprom.WithoutScopeInfo())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res), sdkmetric.WithReader(exporter)}
+	for _, r := range cfg.otel.readers {
+		opts = append(opts, sdkmetric.WithReader(r))
+	}
+	for _, r := range cfg.otel.otlp {
+		exporter := r.exporter
+		if cfg.nativeFactor > 1 {
+			exporter = exponentialExporter{exporter}
+		}
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, r.opts...)))
+	}
+	return sdkmetric.NewMeterProvider(opts...), nil
+}
+
+// otelRecorder records the HTTP metrics with OpenTelemetry instruments.
+type otelRecorder struct {
+	concurrentCalls metric.Int64UpDownCounter
+	totalCalls      metric.Int64Counter
+	callDuration    metric.Float64Histogram
+	requestSize     metric.Int64Histogram
+	responseSize    metric.Int64Histogram
+}
+
+// newOTelRecorder creates the meter provider and the instruments of the HTTP metrics. It panics on errors,
+// in keeping with Initialize.
+func newOTelRecorder() *otelRecorder {
+	var err error
+	if provider, err = newOTelProvider(); err != nil {
+		panic("failed to create the OpenTelemetry metrics backend: " + err.Error())
+	}
+
+	meter := provider.Meter(ScopeName)
+	r := &otelRecorder{}
+	r.concurrentCalls, err = meter.Int64UpDownCounter(mNames[0],
+		metric.WithDescription(concurrentCallsHelp), metric.WithUnit("{call}"))
+	must(err)
+	r.totalCalls, err = meter.Int64Counter(mNames[1],
+		metric.WithDescription(totalCallsHelp), metric.WithUnit("{call}"))
+	must(err)
+	r.callDuration, err = meter.Float64Histogram(mNames[2],
+		metric.WithDescription(callDurationHelp), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(cfg.durationBuckets...))
+	must(err)
+	r.requestSize, err = meter.Int64Histogram(mNames[3],
+		metric.WithDescription(requestSizeHelp), metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(cfg.requestSizeBuckets...))
+	must(err)
+	r.responseSize, err = meter.Int64Histogram(mNames[4],
+		metric.WithDescription(responseSizeHelp), metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(cfg.responseSizeBuckets...))
+	must(err)
+	return r
+}
+
+func must(err error) {
+	if err != nil {
+		panic("failed to create the OpenTelemetry instruments: " + err.Error())
+	}
+}
+
+func (r *otelRecorder) begin(ctx context.Context, start []string) {
+	r.concurrentCalls.Add(ctx, 1, attributeSet(labels.start, start))
+}
+
+func (r *otelRecorder) end(ctx context.Context, m requestMetrics) {
+	api := attributeSet(labels.api(), m.api)
+	size := attributeSet(labels.size(), m.size)
+	r.concurrentCalls.Add(ctx, -1, attributeSet(labels.start, m.start))
+	r.callDuration.Record(ctx, m.duration, api)
+	r.totalCalls.Add(ctx, 1, api)
+	r.requestSize.Record(ctx, m.requestSize, size)
+	r.responseSize.Record(ctx, m.responseSize, size)
+}
+
+// attributeSet returns the label values as an attribute set.
+func attributeSet(names, values []string) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, len(names))
+	for i, name := range names {
+		kvs[i] = attribute.String(name, values[i])
+	}
+	return metric.WithAttributeSet(attribute.NewSet(kvs...))
+}
+
+// exponentialExporter aggregates the histograms as exponential histograms, the OpenTelemetry equivalent of the
+// prometheus native histograms.
+type exponentialExporter struct {
+	sdkmetric.Exporter
+}
+
+func (e exponentialExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	if kind == sdkmetric.InstrumentKindHistogram {
+		return sdkmetric.AggregationBase2ExponentialHistogram{MaxSize: DefaultNativeHistogramMaxBuckets, MaxScale: 20}
+	}
+	return e.Exporter.Aggregation(kind)
+}
@@ -0,0 +1,181 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/metrics"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func gathered(t *testing.T) map[string]*dto.MetricFamily {
+	t.Helper()
+	mfs, err := metrics.Gather()
+	require.NoError(t, err)
+	families := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		families[mf.GetName()] = mf
+	}
+	return families
+}
+
+func TestWithOTel(t *testing.T) {
+	defer metrics.Reset()
+	reader := sdkmetric.NewManualReader()
+	metrics.Initialize("1024", "test",
+		metrics.WithReader(reader),
+		metrics.WithService("0.0.1", "otel_test", "now", "456789", "local"))
+
+	r := newMetricsRouter("/person/:id")
+	hammer(r, 3, func(int) string { return "/person/1" })
+
+	// the metrics keep the names and labels of the prometheus backend.
+	families := gathered(t)
+	total := families["test_metrics_test_total_calls"]
+	require.NotNil(t, total)
+	assert.Equal(t, dto.MetricType_COUNTER, total.GetType())
+	assert.Equal(t, float64(3), total.GetMetric()[0].GetCounter().GetValue())
+	labels := make(map[string]string)
+	for _, lp := range total.GetMetric()[0].GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+	assert.Equal(t, map[string]string{"path": "/person/:id", "http_method": "GET", "status_code": "200"}, labels)
+
+	duration := families["test_metrics_test_call_duration_seconds"]
+	require.NotNil(t, duration)
+	assert.Len(t, duration.GetMetric()[0].GetHistogram().GetBucket(), 11, "the buckets are prometheus.DefBuckets")
+	assert.Contains(t, families, "test_metrics_test_concurrent_calls")
+	assert.Contains(t, families, "test_metrics_test_http_request_size_bytes")
+	assert.Contains(t, families, "test_metrics_test_http_response_size_bytes")
+	assert.Contains(t, families, "target_info")
+
+	// the readers get the same metrics, with the service resource.
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	name, _ := rm.Resource.Set().Value("service.name")
+	assert.Equal(t, "otel_test", name.AsString())
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, metrics.ScopeName, rm.ScopeMetrics[0].Scope.Name)
+	assert.Len(t, rm.ScopeMetrics[0].Metrics, 5)
+
+	// the prometheus collectors are not created; the interfaces must be nil, not hold nil pointers.
+	for _, col := range []prometheus.Collector{metrics.ConcurrentCalls(), metrics.TotalCalls(), metrics.CallDuration(),
+		metrics.RequestSize(), metrics.ResponseSize()} {
+		assert.True(t, col == nil)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	defer metrics.Reset()
+	metrics.Initialize("1024", "test", metrics.WithOTel())
+
+	ctr, err := metrics.Meter().Int64Counter("custom_jobs")
+	require.NoError(t, err)
+	ctr.Add(context.Background(), 2)
+
+	jobs := gathered(t)["test_custom_jobs"]
+	require.NotNil(t, jobs)
+	assert.Equal(t, float64(2), jobs.GetMetric()[0].GetCounter().GetValue())
+	assert.NoError(t, metrics.Shutdown(context.Background()))
+}
+
+func TestMeter_Panics(t *testing.T) {
+	defer metrics.Reset()
+	assert.Panics(t, func() { metrics.Meter() })
+
+	metrics.Initialize("1024", "test")
+	assert.Panics(t, func() { metrics.Meter() })
+	assert.NoError(t, metrics.Shutdown(context.Background()))
+}
+
+// fakeMetricReceiver is an in-process OTLP HTTP receiver that records the metrics it receives.
+type fakeMetricReceiver struct {
+	mu       sync.Mutex
+	metrics  map[string]*metricspb.Metric
+	resource map[string]string
+}
+
+func (r *fakeMetricReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	raw, _ := io.ReadAll(req.Body)
+	ereq := &collectormetrics.ExportMetricsServiceRequest{}
+	if req.URL.Path != "/v1/metrics" || proto.Unmarshal(raw, ereq) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	for _, rm := range ereq.GetResourceMetrics() {
+		r.resource = make(map[string]string)
+		for _, kv := range rm.GetResource().GetAttributes() {
+			r.resource[kv.GetKey()] = kv.GetValue().GetStringValue()
+		}
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				r.metrics[m.GetName()] = m
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	res, _ := proto.Marshal(&collectormetrics.ExportMetricsServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(res)
+}
+
+func TestWithOTLP(t *testing.T) {
+	defer metrics.Reset()
+	rcv := &fakeMetricReceiver{metrics: make(map[string]*metricspb.Metric)}
+	svr := httptest.NewServer(rcv)
+	defer svr.Close()
+
+	exporter, err := metrics.NewOTLPExporter(context.Background(), strings.TrimPrefix(svr.URL, "http://"), otlpmetrichttp.WithInsecure())
+	require.NoError(t, err)
+	metrics.Initialize("1024", "test",
+		metrics.WithOTLP(exporter, sdkmetric.WithInterval(time.Hour)),
+		metrics.WithNativeHistograms(1.1),
+		metrics.WithService("0.0.1", "otel_test", "now", "456789", "local"))
+
+	hammer(newMetricsRouter("/person/:id"), 2, func(int) string { return "/person/1" })
+	require.NoError(t, metrics.Shutdown(context.Background()))
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	assert.Equal(t, "otel_test", rcv.resource["service.name"])
+	assert.Equal(t, "local", rcv.resource["env"])
+
+	total := rcv.metrics["metrics_test_total_calls"]
+	require.NotNil(t, total)
+	point := total.GetSum().GetDataPoints()[0]
+	assert.Equal(t, int64(2), point.GetAsInt())
+	assert.Equal(t, "/person/:id", pointAttributes(point.GetAttributes())["path"])
+
+	// native histograms are pushed as exponential histograms.
+	duration := rcv.metrics["metrics_test_call_duration_seconds"]
+	require.NotNil(t, duration)
+	assert.NotNil(t, duration.GetExponentialHistogram())
+	assert.Equal(t, "s", duration.GetUnit())
+}
+
+func pointAttributes(kvs []*commonpb.KeyValue) map[string]string {
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		m[kv.GetKey()] = kv.GetValue().GetStringValue()
+	}
+	return m
+}
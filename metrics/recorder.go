@@ -0,0 +1,34 @@
+package metrics
+
+import "context"
+
+// recorder records the HTTP metrics of the requests, in the backend selected when initializing.
+type recorder interface {
+	begin(ctx context.Context, start []string)
+	end(ctx context.Context, m requestMetrics)
+}
+
+// requestMetrics is the label values and the measurements of a request.
+type requestMetrics struct {
+	start        []string
+	api          []string
+	size         []string
+	duration     float64
+	requestSize  int64
+	responseSize int64
+}
+
+// promRecorder records the HTTP metrics in the prometheus collectors.
+type promRecorder struct{}
+
+func (promRecorder) begin(_ context.Context, start []string) {
+	concurrentCalls.WithLabelValues(start...).Inc()
+}
+
+func (promRecorder) end(_ context.Context, m requestMetrics) {
+	concurrentCalls.WithLabelValues(m.start...).Dec()
+	callDuration.WithLabelValues(m.api...).Observe(m.duration)
+	totalCalls.WithLabelValues(m.api...).Inc()
+	requestSize.WithLabelValues(m.size...).Observe(float64(m.requestSize))
+	responseSize.WithLabelValues(m.size...).Observe(float64(m.responseSize))
+}
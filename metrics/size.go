@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// bodyCounter counts the bytes read from a request body whose length is unknown, e.g., a chunked body.
+type bodyCounter struct {
+	io.ReadCloser
+	length int64
+	read   atomic.Int64
+}
+
+// countBody returns the counter of the request body, replacing the body if its length is unknown.
+func countBody(req *http.Request) *bodyCounter {
+	bc := &bodyCounter{length: req.ContentLength}
+	if req.ContentLength < 0 && req.Body != nil && req.Body != http.NoBody {
+		bc.ReadCloser = req.Body
+		req.Body = bc
+	}
+	return bc
+}
+
+func (bc *bodyCounter) Read(p []byte) (int, error) {
+	n, err := bc.ReadCloser.Read(p)
+	bc.read.Add(int64(n))
+	return n, err
+}
+
+// size returns the Content-Length of the request, or the bytes read by the handlers if it is unknown.
+func (bc *bodyCounter) size() int64 {
+	if bc.length >= 0 {
+		return bc.length
+	}
+	return bc.read.Load()
+}
+
+// statusClassOf returns the class of the status code, e.g., "2xx".
+func statusClassOf(status int) string {
+	if status < 100 || status > 599 {
+		return strconv.Itoa(status)
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,180 @@
+// Package requestid provides a gin middleware that gives every request an id, so requests can be correlated
+// across services and logs even when they are not traced.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twistingmercury/monitoring/logs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultHeader is the header that carries the request id.
+	DefaultHeader = "X-Request-ID"
+	// DefaultMaxLength is the longest incoming request id that is accepted.
+	DefaultMaxLength = 128
+	// RequestIDAttr is the log field and span attribute that holds the request id.
+	RequestIDAttr = "http.request.id"
+)
+
+// Option configures the middleware and the transport.
+type Option func(*config)
+
+type config struct {
+	header    string
+	maxLength int
+	validate  func(string) bool
+	generate  func() string
+}
+
+func newConfig(opts ...Option) config {
+	cfg := config{
+		header:    DefaultHeader,
+		maxLength: DefaultMaxLength,
+		validate:  validChars,
+		generate:  Generate,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithHeader sets the header that carries the request id, e.g., "X-Correlation-ID".
+func WithHeader(name string) Option {
+	return func(c *config) {
+		c.header = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithMaxLength sets the longest incoming request id that is accepted. Longer ids are replaced.
+func WithMaxLength(n int) Option {
+	return func(c *config) {
+		c.maxLength = n
+	}
+}
+
+// WithValidator sets the func that accepts the incoming request ids. Rejected ids are replaced. By default,
+// ids made of letters, digits and -_.:+/=@ are accepted. The max length is checked before the validator is called.
+func WithValidator(valid func(id string) bool) Option {
+	return func(c *config) {
+		c.validate = valid
+	}
+}
+
+// WithGenerator sets the func that generates the ids of the requests that don't have a valid one. By default,
+// a UUIDv7 is generated; see Generate.
+func WithGenerator(generate func() string) Option {
+	return func(c *config) {
+		c.generate = generate
+	}
+}
+
+// Generate returns a new UUIDv7. UUIDv7 ids are time ordered, so they sort by the time the request was received.
+func Generate() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx that carries the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request id found in the ctx, or an empty string. ctx can be a *gin.Context.
+func FromContext(ctx context.Context) string {
+	if gc, ok := ctx.(*gin.Context); ok && gc.Request != nil {
+		ctx = gc.Request.Context()
+	}
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// GinRequestIDMiddleware gives each request an id. The id of the incoming header is used if it is valid,
+// otherwise one is generated. The id is echoed in the response header, stored in the request context, added
+// to the request logger of the logs package, so the access log and the entries of the request have it, and set
+// on the server span, if any. Register it after traces.GinTracingMiddleware so the span exists:
+//
+//	r.Use(traces.GinTracingMiddleware(), requestid.GinRequestIDMiddleware(), logs.GinLoggingMiddleware())
+func GinRequestIDMiddleware(opts ...Option) gin.HandlerFunc {
+	cfg := newConfig(opts...)
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(cfg.header)
+		if !cfg.valid(id) {
+			id = cfg.generate()
+		}
+
+		c.Header(cfg.header, id)
+		ctx := NewContext(c.Request.Context(), id)
+		ctx = logs.With(ctx, map[string]any{RequestIDAttr: id})
+		c.Request = c.Request.WithContext(ctx)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String(RequestIDAttr, id))
+
+		c.Next()
+	}
+}
+
+func (c config) valid(id string) bool {
+	if len(id) == 0 || len(id) > c.maxLength {
+		return false
+	}
+	return c.validate(id)
+}
+
+// validChars reports whether the id is made of characters that are safe to log and to forward.
+func validChars(id string) bool {
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '+', r == '/', r == '=', r == '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// transport sets the request id header on outgoing requests.
+type transport struct {
+	base   http.RoundTripper
+	header string
+}
+
+// NewTransport returns an http.RoundTripper that sets the request id found in the context of the outgoing
+// request, so the services called while handling a request get its id. Requests that already have the header,
+// or whose context has no request id, are sent as is. If base is nil, http.DefaultTransport is used.
+//
+//	client := &http.Client{Transport: requestid.NewTransport(nil)}
+//	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, url, nil)
+func NewTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, header: newConfig(opts...).header}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if len(id) == 0 || len(req.Header.Get(t.header)) > 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, id)
+	return t.base.RoundTrip(req)
+}
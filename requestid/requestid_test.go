@@ -0,0 +1,162 @@
+package requestid_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/logs"
+	"github.com/twistingmercury/monitoring/requestid"
+	"github.com/twistingmercury/monitoring/traces"
+	"github.com/twistingmercury/monitoring/traces/tracetest"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func newRouter(t *testing.T, middlewares ...gin.HandlerFunc) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	tout := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "0.0.1", "requestid_test", "now", "456789", "local", tout)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares...)
+	r.GET("/person/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c))
+	})
+	return r, tout
+}
+
+func serve(r *gin.Engine, header, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/person/1", nil)
+	if len(id) > 0 {
+		req.Header.Set(header, id)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGinRequestIDMiddleware_Generates(t *testing.T) {
+	r, _ := newRouter(t, requestid.GinRequestIDMiddleware())
+
+	w := serve(r, requestid.DefaultHeader, "")
+	id := w.Header().Get(requestid.DefaultHeader)
+	u, err := uuid.Parse(id)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), u.Version())
+	assert.Equal(t, id, w.Body.String())
+
+	assert.NotEqual(t, id, serve(r, requestid.DefaultHeader, "").Header().Get(requestid.DefaultHeader))
+}
+
+func TestGinRequestIDMiddleware_Incoming(t *testing.T) {
+	r, _ := newRouter(t, requestid.GinRequestIDMiddleware(requestid.WithMaxLength(16)))
+
+	tests := []struct {
+		name string
+		id   string
+		kept bool
+	}{
+		{name: "valid", id: "abc-123_x.y", kept: true},
+		{name: "too long", id: strings.Repeat("a", 17), kept: false},
+		{name: "invalid characters", id: "abc\n{\"admin\":1}", kept: false},
+		{name: "spaces", id: "abc 123", kept: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := serve(r, requestid.DefaultHeader, tt.id).Header().Get(requestid.DefaultHeader)
+			assert.NotEmpty(t, id)
+			assert.Equal(t, tt.kept, id == tt.id)
+		})
+	}
+}
+
+func TestGinRequestIDMiddleware_Options(t *testing.T) {
+	r, _ := newRouter(t, requestid.GinRequestIDMiddleware(
+		requestid.WithHeader("x-correlation-id"),
+		requestid.WithValidator(func(id string) bool { return strings.HasPrefix(id, "req-") }),
+		requestid.WithGenerator(func() string { return "generated" })))
+
+	assert.Equal(t, "req-1", serve(r, "X-Correlation-ID", "req-1").Header().Get("X-Correlation-ID"))
+	assert.Equal(t, "generated", serve(r, "X-Correlation-ID", "other").Header().Get("X-Correlation-ID"))
+	assert.Empty(t, serve(r, "X-Correlation-ID", "req-1").Header().Get(requestid.DefaultHeader))
+}
+
+func TestGinRequestIDMiddleware_Logs(t *testing.T) {
+	// the id reaches the access log whether the middleware runs before or after the logging middleware.
+	orders := map[string]func() []gin.HandlerFunc{
+		"before": func() []gin.HandlerFunc {
+			return []gin.HandlerFunc{requestid.GinRequestIDMiddleware(), logs.GinLoggingMiddleware()}
+		},
+		"after": func() []gin.HandlerFunc {
+			return []gin.HandlerFunc{logs.GinLoggingMiddleware(), requestid.GinRequestIDMiddleware()}
+		},
+	}
+	for name, middlewares := range orders {
+		t.Run(name, func(t *testing.T) {
+			tout := &bytes.Buffer{}
+			logs.Initialize(zerolog.DebugLevel, "0.0.1", "requestid_test", "now", "456789", "local", tout)
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middlewares()...)
+			r.GET("/person/:id", func(c *gin.Context) {
+				logs.Info(c, "loading", nil)
+				c.Status(http.StatusOK)
+			})
+
+			serve(r, requestid.DefaultHeader, "req-42")
+
+			lines := strings.Split(strings.TrimSpace(tout.String()), "\n")
+			require.Len(t, lines, 2)
+			for _, line := range lines {
+				var entry map[string]any
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+				assert.Equal(t, "req-42", entry[requestid.RequestIDAttr])
+			}
+		})
+	}
+}
+
+func TestGinRequestIDMiddleware_Span(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	r, _ := newRouter(t, traces.GinTracingMiddleware(), requestid.GinRequestIDMiddleware())
+
+	serve(r, requestid.DefaultHeader, "req-42")
+	rec.AssertSpan(t, "GET /person/:id", tracetest.HasAttribute(attribute.String(requestid.RequestIDAttr, "req-42")))
+}
+
+func TestNewTransport(t *testing.T) {
+	var got []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestid.DefaultHeader))
+	}))
+	defer svr.Close()
+
+	client := &http.Client{Transport: requestid.NewTransport(nil)}
+	send := func(ctx context.Context, header string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, svr.URL, nil)
+		require.NoError(t, err)
+		if len(header) > 0 {
+			req.Header.Set(requestid.DefaultHeader, header)
+		}
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, header, req.Header.Get(requestid.DefaultHeader), "the request must not be modified")
+	}
+
+	ctx := requestid.NewContext(context.Background(), "req-42")
+	send(ctx, "")
+	send(ctx, "explicit")
+	send(context.Background(), "")
+	assert.Equal(t, []string{"req-42", "explicit", ""}, got)
+}
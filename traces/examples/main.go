@@ -17,8 +17,13 @@ func main() {
 	}
 
 	////... or create a grpc exporter
-	// grpcConn, _ := grpc.Dial("localhost:4317", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	// exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(grpcConn))
+	// exporter, err := traces.NewGRPCExporter(ctx, "localhost:4317", otlptracegrpc.WithInsecure())
+	// if err != nil {
+	// 	panic(err)
+	// }
+
+	////... or let the OTEL_EXPORTER_OTLP_* environment variables decide
+	// exporter, err := traces.NewExporterFromEnv(ctx)
 	// if err != nil {
 	// 	panic(err)
 	// }
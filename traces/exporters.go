@@ -0,0 +1,52 @@
+package traces
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// EnvExporterProtocol is the environment variable used to select the OTLP protocol.
+	EnvExporterProtocol = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	// EnvExporterTracesProtocol is the environment variable used to select the OTLP protocol for traces.
+	// It takes precedence over OTEL_EXPORTER_OTLP_PROTOCOL.
+	EnvExporterTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// NewGRPCExporter creates a new OTLP gRPC exporter. The endpoint is the host and port of the collector or
+// agent, e.g., "localhost:4317". TLS, headers, compression, retries and timeouts are configured with the
+// otlptracegrpc options, e.g., otlptracegrpc.WithInsecure, otlptracegrpc.WithTLSCredentials,
+// otlptracegrpc.WithHeaders, otlptracegrpc.WithCompressor, otlptracegrpc.WithRetry and otlptracegrpc.WithTimeout.
+func NewGRPCExporter(ctx context.Context, endpoint string, opts ...otlptracegrpc.Option) (exporter sdktrace.SpanExporter, err error) {
+	opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// NewExporterFromEnv creates an OTLP exporter configured by the standard OTEL_EXPORTER_OTLP_* environment
+// variables. The protocol is selected by OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or OTEL_EXPORTER_OTLP_PROTOCOL;
+// "grpc" and "http/protobuf" are supported, and "http/protobuf" is the default. The endpoint, headers,
+// certificate, compression and timeout are read from the environment by the exporter itself.
+func NewExporterFromEnv(ctx context.Context) (exporter sdktrace.SpanExporter, err error) {
+	protocol := os.Getenv(EnvExporterTracesProtocol)
+	if len(protocol) == 0 {
+		protocol = os.Getenv(EnvExporterProtocol)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(protocol)) {
+	case ProtocolGRPC:
+		return otlptracegrpc.New(ctx)
+	case ProtocolHTTPProtobuf, "":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol: `%s`; supported protocols are `%s` and `%s`", protocol, ProtocolGRPC, ProtocolHTTPProtobuf)
+	}
+}
@@ -0,0 +1,185 @@
+package traces_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/traces"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeReceiver is an in-process OTLP receiver that records the names of the spans it receives,
+// and the headers (or gRPC metadata) of the last request.
+type fakeReceiver struct {
+	collectortrace.UnimplementedTraceServiceServer
+	mu      sync.Mutex
+	names   []string
+	headers map[string][]string
+}
+
+func (r *fakeReceiver) record(req *collectortrace.ExportTraceServiceRequest, headers map[string][]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headers = headers
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				r.names = append(r.names, s.GetName())
+			}
+		}
+	}
+}
+
+func (r *fakeReceiver) spanNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+func (r *fakeReceiver) header(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v := r.headers[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (r *fakeReceiver) Export(ctx context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.record(req, md)
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (r *fakeReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	raw, _ := io.ReadAll(body)
+
+	ereq := &collectortrace.ExportTraceServiceRequest{}
+	if req.URL.Path != "/v1/traces" || proto.Unmarshal(raw, ereq) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	headers := make(map[string][]string)
+	for k, v := range req.Header {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	r.record(ereq, headers)
+
+	res, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(res)
+}
+
+func startGRPCReceiver(t *testing.T) (*fakeReceiver, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	rcv := &fakeReceiver{}
+	svr := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(svr, rcv)
+	go func() { _ = svr.Serve(l) }()
+	t.Cleanup(svr.Stop)
+	return rcv, l.Addr().String()
+}
+
+// exportSpan sends a single span through exporter, synchronously.
+func exportSpan(t *testing.T, exporter sdktrace.SpanExporter, name string) {
+	t.Helper()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := tp.Tracer("exporters_test").Start(context.Background(), name, trace.WithSpanKind(trace.SpanKindServer))
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestNewGRPCExporter(t *testing.T) {
+	rcv, addr := startGRPCReceiver(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exporter, err := traces.NewGRPCExporter(ctx, addr,
+		otlptracegrpc.WithInsecure(),
+		otlptracegrpc.WithHeaders(map[string]string{"api-key": "secret"}),
+		otlptracegrpc.WithCompressor("gzip"),
+		otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: true, InitialInterval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, MaxElapsedTime: time.Second}),
+		otlptracegrpc.WithTimeout(5*time.Second),
+	)
+	require.NoError(t, err)
+
+	exportSpan(t, exporter, "grpc span")
+	assert.Equal(t, []string{"grpc span"}, rcv.spanNames())
+	assert.Equal(t, "secret", rcv.header("api-key"))
+}
+
+func TestNewExporterFromEnv_GRPC(t *testing.T) {
+	rcv, addr := startGRPCReceiver(t)
+	t.Setenv(traces.EnvExporterProtocol, traces.ProtocolGRPC)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+addr)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=from-env")
+
+	exporter, err := traces.NewExporterFromEnv(context.Background())
+	require.NoError(t, err)
+
+	exportSpan(t, exporter, "grpc env span")
+	assert.Equal(t, []string{"grpc env span"}, rcv.spanNames())
+	assert.Equal(t, "from-env", rcv.header("api-key"))
+}
+
+func TestNewExporterFromEnv_HTTP(t *testing.T) {
+	rcv := &fakeReceiver{}
+	svr := httptest.NewTLSServer(rcv)
+	defer svr.Close()
+
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw})
+	require.NoError(t, os.WriteFile(certFile, pemCert, 0o600))
+
+	// the signal specific protocol takes precedence.
+	t.Setenv(traces.EnvExporterProtocol, traces.ProtocolGRPC)
+	t.Setenv(traces.EnvExporterTracesProtocol, traces.ProtocolHTTPProtobuf)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", svr.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=from-env")
+	t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", certFile)
+
+	exporter, err := traces.NewExporterFromEnv(context.Background())
+	require.NoError(t, err)
+
+	exportSpan(t, exporter, "http env span")
+	assert.Equal(t, []string{"http env span"}, rcv.spanNames())
+	assert.Equal(t, "from-env", rcv.header("Api-Key"))
+}
+
+func TestNewExporterFromEnv_Unsupported(t *testing.T) {
+	t.Setenv(traces.EnvExporterProtocol, "http/json")
+	_, err := traces.NewExporterFromEnv(context.Background())
+	assert.Error(t, err)
+}
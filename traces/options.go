@@ -0,0 +1,177 @@
+package traces
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Option configures a Provider.
+type Option func(*config)
+
+type config struct {
+	serviceName    string
+	serviceVersion string
+	buildDate      string
+	commitHash     string
+	env            string
+	resourceAttrs  []attribute.KeyValue
+	detectors      []resource.Detector
+	exporters      []sdktrace.SpanExporter
+	processors     []sdktrace.SpanProcessor
+	batchOpts      []sdktrace.BatchSpanProcessorOption
+	idGenerator    sdktrace.IDGenerator
+	propagators    []Propagator
+	unmatchedName  string
+	sampler        sdktrace.Sampler
+	sampleErrors   bool
+	query          QueryPolicy
+}
+
+func newConfig(opts ...Option) config {
+	cfg := config{
+		propagators:   DefaultPropagators(),
+		unmatchedName: DefaultUnmatchedRouteName,
+		query:         DefaultQueryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithServiceName sets the service.name resource attribute. It is also used as the name of the tracer.
+func WithServiceName(name string) Option {
+	return func(c *config) {
+		c.serviceName = name
+	}
+}
+
+// WithServiceVersion sets the service.version resource attribute.
+func WithServiceVersion(version string) Option {
+	return func(c *config) {
+		c.serviceVersion = version
+	}
+}
+
+// WithBuildDate sets the buildDate resource attribute.
+func WithBuildDate(buildDate string) Option {
+	return func(c *config) {
+		c.buildDate = buildDate
+	}
+}
+
+// WithCommitHash sets the commitHash resource attribute.
+func WithCommitHash(commitHash string) Option {
+	return func(c *config) {
+		c.commitHash = commitHash
+	}
+}
+
+// WithEnvironment sets the env resource attribute.
+func WithEnvironment(env string) Option {
+	return func(c *config) {
+		c.env = env
+	}
+}
+
+// WithResourceAttributes adds attributes to the resource shared by all spans.
+func WithResourceAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.resourceAttrs = append(c.resourceAttrs, attrs...)
+	}
+}
+
+// WithResourceDetectors adds detectors, e.g., host or container detectors, whose attributes are merged
+// into the resource shared by all spans.
+func WithResourceDetectors(detectors ...resource.Detector) Option {
+	return func(c *config) {
+		c.detectors = append(c.detectors, detectors...)
+	}
+}
+
+// WithExporter exports spans with the exporter using a batch span processor. The batch processor can be
+// tuned with the WithBatch* options. This option can be supplied multiple times.
+func WithExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *config) {
+		c.exporters = append(c.exporters, exporter)
+	}
+}
+
+// WithSpanProcessor registers a span processor, e.g., a simple span processor for tests.
+// This option can be supplied multiple times.
+func WithSpanProcessor(processor sdktrace.SpanProcessor) Option {
+	return func(c *config) {
+		c.processors = append(c.processors, processor)
+	}
+}
+
+// WithBatchMaxQueueSize sets the maximum number of spans buffered by the batch span processor.
+func WithBatchMaxQueueSize(size int) Option {
+	return func(c *config) {
+		c.batchOpts = append(c.batchOpts, sdktrace.WithMaxQueueSize(size))
+	}
+}
+
+// WithBatchMaxExportBatchSize sets the maximum number of spans exported in a single batch.
+func WithBatchMaxExportBatchSize(size int) Option {
+	return func(c *config) {
+		c.batchOpts = append(c.batchOpts, sdktrace.WithMaxExportBatchSize(size))
+	}
+}
+
+// WithBatchTimeout sets the maximum delay before a batch is exported.
+func WithBatchTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.batchOpts = append(c.batchOpts, sdktrace.WithBatchTimeout(timeout))
+	}
+}
+
+// WithBatchExportTimeout sets the time allowed for a single export.
+func WithBatchExportTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.batchOpts = append(c.batchOpts, sdktrace.WithExportTimeout(timeout))
+	}
+}
+
+// WithIDGenerator sets the generator of the trace and span ids.
+func WithIDGenerator(generator sdktrace.IDGenerator) Option {
+	return func(c *config) {
+		c.idGenerator = generator
+	}
+}
+
+// WithPropagators sets the formats used to extract the incoming and inject the outgoing span context.
+// The default is W3C trace context and baggage.
+func WithPropagators(propagators ...Propagator) Option {
+	return func(c *config) {
+		c.propagators = propagators
+	}
+}
+
+// WithUnmatchedRouteName sets the name of the server spans for requests that don't match a gin route,
+// e.g., 404s. The default is DefaultUnmatchedRouteName.
+func WithUnmatchedRouteName(name string) Option {
+	return func(c *config) {
+		c.unmatchedName = name
+	}
+}
+
+// WithSampler sets the sampler. If not set, the sampler selected by the OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG environment variables is used, see SamplerFromEnv. If those are not set either,
+// every span is sampled.
+func WithSampler(sampler sdktrace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = sampler
+	}
+}
+
+// WithErrorSampling exports spans that end with an error status even if the sampler did not sample them.
+// Spans the sampler drops are recorded, and the decision to export them is made when they end.
+func WithErrorSampling() Option {
+	return func(c *config) {
+		c.sampleErrors = true
+	}
+}
@@ -0,0 +1,144 @@
+package traces
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Propagator identifies a context propagation format. The values match the ones
+// used by the OTEL_PROPAGATORS environment variable.
+type Propagator string
+
+const (
+	PropagatorTraceContext Propagator = "tracecontext" // W3C traceparent / tracestate
+	PropagatorBaggage      Propagator = "baggage"      // W3C baggage
+	PropagatorB3           Propagator = "b3"           // B3 single header
+	PropagatorB3Multi      Propagator = "b3multi"      // B3 multiple headers
+	PropagatorJaeger       Propagator = "jaeger"       // uber-trace-id
+	PropagatorDatadog      Propagator = "datadog"      // x-datadog-* headers
+)
+
+// DefaultPropagators are the propagators used when none are supplied.
+func DefaultPropagators() []Propagator {
+	return []Propagator{PropagatorTraceContext, PropagatorBaggage}
+}
+
+// NewPropagator returns a composite propagator for the supplied formats. When extracting, the
+// formats are tried in the order supplied, the last one with a valid span context wins.
+func NewPropagator(names ...Propagator) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		names = DefaultPropagators()
+	}
+
+	props := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		switch Propagator(strings.ToLower(strings.TrimSpace(string(name)))) {
+		case PropagatorTraceContext:
+			props = append(props, propagation.TraceContext{})
+		case PropagatorBaggage:
+			props = append(props, propagation.Baggage{})
+		case PropagatorB3:
+			props = append(props, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			props = append(props, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorJaeger:
+			props = append(props, jaeger.Jaeger{})
+		case PropagatorDatadog:
+			props = append(props, DatadogPropagator{})
+		default:
+			return nil, fmt.Errorf("unsupported propagator: `%s`", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(props...), nil
+}
+
+const (
+	ddTraceIDHeader  = "x-datadog-trace-id"
+	ddParentIDHeader = "x-datadog-parent-id"
+	ddPriorityHeader = "x-datadog-sampling-priority"
+	ddTagsHeader     = "x-datadog-tags"
+	ddTraceIDTag     = "_dd.p.tid"
+)
+
+// DatadogPropagator propagates span context using the x-datadog-* headers. Datadog uses
+// 64 bit decimal ids; the upper 64 bits of the OTel trace id are carried in the _dd.p.tid tag.
+type DatadogPropagator struct{}
+
+var _ propagation.TextMapPropagator = DatadogPropagator{}
+
+// Inject sets the x-datadog-* headers from the span context found in ctx.
+func (DatadogPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	tid := sc.TraceID()
+	sid := sc.SpanID()
+	carrier.Set(ddTraceIDHeader, strconv.FormatUint(binary.BigEndian.Uint64(tid[8:]), 10))
+	carrier.Set(ddParentIDHeader, strconv.FormatUint(binary.BigEndian.Uint64(sid[:]), 10))
+
+	priority := "0"
+	if sc.IsSampled() {
+		priority = "1"
+	}
+	carrier.Set(ddPriorityHeader, priority)
+
+	if upper := binary.BigEndian.Uint64(tid[:8]); upper != 0 {
+		carrier.Set(ddTagsHeader, ddTraceIDTag+"="+hex.EncodeToString(tid[:8]))
+	}
+}
+
+// Extract reads the x-datadog-* headers and returns a context with the remote span context.
+func (DatadogPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	lower, err := strconv.ParseUint(carrier.Get(ddTraceIDHeader), 10, 64)
+	if err != nil || lower == 0 {
+		return ctx
+	}
+	parent, err := strconv.ParseUint(carrier.Get(ddParentIDHeader), 10, 64)
+	if err != nil || parent == 0 {
+		return ctx
+	}
+
+	var tid trace.TraceID
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(tid[8:], lower)
+	binary.BigEndian.PutUint64(sid[:], parent)
+
+	for _, tag := range strings.Split(carrier.Get(ddTagsHeader), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok || k != ddTraceIDTag {
+			continue
+		}
+		if upper, err := hex.DecodeString(v); err == nil && len(upper) == 8 {
+			copy(tid[:8], upper)
+		}
+	}
+
+	var flags trace.TraceFlags
+	if p, err := strconv.Atoi(carrier.Get(ddPriorityHeader)); err == nil && p > 0 {
+		flags = trace.FlagsSampled
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields returns the headers used by the propagator.
+func (DatadogPropagator) Fields() []string {
+	return []string{ddTraceIDHeader, ddParentIDHeader, ddPriorityHeader, ddTagsHeader}
+}
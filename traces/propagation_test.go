@@ -0,0 +1,191 @@
+package traces_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/traces"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingExporter keeps the exported spans after shutdown so they can be inspected.
+type recordingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (recordingExporter) Shutdown(context.Context) error { return nil }
+
+func newRecordingExporter() recordingExporter {
+	return recordingExporter{tracetest.NewInMemoryExporter()}
+}
+
+func serverSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.SpanKind == trace.SpanKindServer && s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("server span %s not found", name)
+	return tracetest.SpanStub{}
+}
+
+func TestGinTracingMiddleware_ContinuesTraceAcrossServers(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+
+	downstream := gin.New()
+	downstream.Use(traces.GinTracingMiddleware())
+	downstream.GET("/downstream", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	dsvr := httptest.NewServer(downstream)
+	defer dsvr.Close()
+
+	var clientSpanID trace.SpanID
+	upstream := gin.New()
+	upstream.Use(traces.GinTracingMiddleware())
+	upstream.GET("/upstream", func(c *gin.Context) {
+		ctx, span, err := traces.Start(c.Request.Context(), "call downstream", trace.SpanKindClient)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		defer span.End()
+		clientSpanID = span.SpanContext().SpanID()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, dsvr.URL+"/downstream", nil)
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		_ = res.Body.Close()
+		c.Status(res.StatusCode)
+	})
+	usvr := httptest.NewServer(upstream)
+	defer usvr.Close()
+
+	res, err := http.Get(usvr.URL + "/upstream")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	require.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	ds := serverSpan(t, spans, "GET /downstream")
+	assert.True(t, ds.Parent.IsRemote())
+	assert.Equal(t, clientSpanID, ds.Parent.SpanID())
+	assert.Equal(t, ds.Parent.TraceID(), ds.SpanContext.TraceID())
+}
+
+func TestGinTracingMiddleware_ExtractsConfiguredFormat(t *testing.T) {
+	tests := []traces.Propagator{
+		traces.PropagatorTraceContext,
+		traces.PropagatorB3,
+		traces.PropagatorB3Multi,
+		traces.PropagatorJaeger,
+		traces.PropagatorDatadog,
+	}
+
+	for _, name := range tests {
+		t.Run(string(name), func(t *testing.T) {
+			exp := newRecordingExporter()
+			shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+				traces.WithPropagators(name))
+			require.NoError(t, err)
+			defer traces.Reset()
+
+			// the caller's span is created by an unrelated provider, as if it were another service.
+			callerTP := sdktrace.NewTracerProvider()
+			callerCtx, caller := callerTP.Tracer("caller").Start(context.Background(), "caller")
+			defer caller.End()
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(traces.GinTracingMiddleware())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			prop, err := traces.NewPropagator(name)
+			require.NoError(t, err)
+			prop.Inject(callerCtx, propagation.HeaderCarrier(req.Header))
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.NoError(t, shutdown(context.Background()))
+
+			s := serverSpan(t, exp.GetSpans(), "GET /")
+			assert.Equal(t, caller.SpanContext().TraceID(), s.SpanContext.TraceID())
+			assert.Equal(t, caller.SpanContext().SpanID(), s.Parent.SpanID())
+		})
+	}
+}
+
+func TestGinTracingMiddleware_NoIncomingContext(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.NoError(t, shutdown(context.Background()))
+
+	s := serverSpan(t, exp.GetSpans(), "GET /")
+	assert.False(t, s.Parent.IsValid())
+}
+
+func TestNewPropagator(t *testing.T) {
+	p, err := traces.NewPropagator()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, p.Fields())
+
+	_, err = traces.NewPropagator("bogus")
+	assert.Error(t, err)
+
+	_, err = traces.Initialize(traces.NewNoopExporter(), "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithPropagators("bogus"))
+	assert.Error(t, err)
+}
+
+func TestDatadogPropagator(t *testing.T) {
+	tid, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	sid, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	carrier := propagation.MapCarrier{}
+	traces.DatadogPropagator{}.Inject(ctx, carrier)
+	assert.Equal(t, "9532127138774266268", carrier.Get("x-datadog-trace-id"))
+	assert.Equal(t, "13235353014750950193", carrier.Get("x-datadog-parent-id"))
+	assert.Equal(t, "1", carrier.Get("x-datadog-sampling-priority"))
+	assert.Equal(t, "_dd.p.tid=0af7651916cd43dd", carrier.Get("x-datadog-tags"))
+
+	extracted := trace.SpanContextFromContext(traces.DatadogPropagator{}.Extract(context.Background(), carrier))
+	assert.Equal(t, tid, extracted.TraceID())
+	assert.Equal(t, sid, extracted.SpanID())
+	assert.True(t, extracted.IsSampled())
+	assert.True(t, extracted.IsRemote())
+
+	empty := traces.DatadogPropagator{}.Extract(context.Background(), propagation.MapCarrier{})
+	assert.False(t, trace.SpanContextFromContext(empty).IsValid())
+}
@@ -0,0 +1,220 @@
+package traces
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/twistingmercury/monitoring/internal/identity"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultTracerName = "github.com/twistingmercury/monitoring/traces"
+
+// Provider owns a tracer provider and the settings used by the gin middleware. A Provider is never
+// modified once created, so it can be used concurrently.
+type Provider struct {
+	tp            *sdktrace.TracerProvider
+	tracer        trace.Tracer
+	propagator    propagation.TextMapPropagator
+	res           *resource.Resource
+	unmatchedName string
+	query         QueryPolicy
+}
+
+// New creates a Provider. It does not replace the default used by the package level functions
+// nor the OTel globals; use SetDefault for that.
+func New(opts ...Option) (*Provider, error) {
+	cfg := newConfig(opts...)
+
+	prop, err := NewPropagator(cfg.propagators...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := cfg.sampler
+	if sampler == nil {
+		sampler = SamplerFromEnv()
+	}
+	if sampler == nil {
+		sampler = sdktrace.AlwaysSample()
+	}
+	if cfg.sampleErrors {
+		sampler = recordErrorsSampler{sampler}
+	}
+
+	processors := make([]sdktrace.SpanProcessor, 0, len(cfg.exporters)+len(cfg.processors))
+	for _, exporter := range cfg.exporters {
+		processors = append(processors, sdktrace.NewBatchSpanProcessor(exporter, cfg.batchOpts...))
+	}
+	processors = append(processors, cfg.processors...)
+
+	tpOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	}
+	for _, sp := range processors {
+		if cfg.sampleErrors {
+			sp = errorSpanProcessor{sp}
+		}
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(sp))
+	}
+	if cfg.idGenerator != nil {
+		tpOpts = append(tpOpts, sdktrace.WithIDGenerator(cfg.idGenerator))
+	}
+
+	tp := sdktrace.NewTracerProvider(tpOpts...)
+
+	name := cfg.serviceName
+	if len(name) == 0 {
+		name = defaultTracerName
+	}
+
+	return &Provider{
+		tp:            tp,
+		tracer:        tp.Tracer(name),
+		propagator:    prop,
+		res:           res,
+		unmatchedName: cfg.unmatchedName,
+		query:         cfg.query,
+	}, nil
+}
+
+// newResource creates the resource shared by all spans. The service identity is set on the resource
+// rather than on each span.
+func newResource(cfg config) (*resource.Resource, error) {
+	svc := identity.Service{
+		Name:       cfg.serviceName,
+		Version:    cfg.serviceVersion,
+		BuildDate:  cfg.buildDate,
+		CommitHash: cfg.commitHash,
+		Env:        cfg.env,
+	}
+	return svc.Resource(cfg.detectors, cfg.resourceAttrs...)
+}
+
+// SetDefault makes p the Provider used by the package level functions, and registers its tracer
+// provider and propagator as the OTel globals. If p is nil, the package level functions are no longer
+// initialized; the OTel globals are not changed.
+func SetDefault(p *Provider) {
+	if p == nil {
+		current.Store(nil)
+		return
+	}
+	otel.SetTracerProvider(p.tp)
+	otel.SetTextMapPropagator(p.propagator)
+	current.Store(p)
+}
+
+// Default returns the Provider used by the package level functions, or nil if the tracing system
+// is not initialized.
+func Default() *Provider {
+	return current.Load()
+}
+
+// TracerProvider returns the underlying OTel tracer provider.
+func (p *Provider) TracerProvider() *sdktrace.TracerProvider {
+	return p.tp
+}
+
+// Tracer returns the tracer used to start spans.
+func (p *Provider) Tracer() trace.Tracer {
+	return p.tracer
+}
+
+// Propagator returns the propagator used to extract and inject span context.
+func (p *Provider) Propagator() propagation.TextMapPropagator {
+	return p.propagator
+}
+
+// Resource returns the resource shared by all spans.
+func (p *Provider) Resource() *resource.Resource {
+	return p.res
+}
+
+// Start starts a new span. If ctx contains a span, the new span is its child.
+// The attributes are added to the new span only.
+func (p *Provider) Start(ctx context.Context, spanName string, kind trace.SpanKind, attributes ...attribute.KeyValue) (spanCtx context.Context, span trace.Span, err error) {
+	if ctx == nil {
+		err = fmt.Errorf("context is nil")
+		return
+	}
+
+	spanCtx, span = p.tracer.Start(
+		ctx,
+		spanName,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attributes...))
+	return
+}
+
+// GinTracingMiddleware returns the tracing middleware for p. See the package level GinTracingMiddleware.
+func (p *Provider) GinTracingMiddleware() gin.HandlerFunc {
+	return p.serve
+}
+
+// ForceFlush exports all the ended spans that have not yet been exported.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.tp.ForceFlush(ctx)
+}
+
+// Shutdown flushes and stops the span processors.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.tp.Shutdown(ctx)
+}
+
+func (p *Provider) serve(c *gin.Context) {
+	parentCtx := p.propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+	spanCtx, span := p.tracer.Start(
+		parentCtx,
+		spanName(c, p.unmatchedName),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(requestAttributes(c, p.query)...))
+
+	// handlers, and anything they call, see the server span as the active span.
+	c.Request = c.Request.WithContext(spanCtx)
+
+	c.Set("trace_id", span.SpanContext().TraceID().String())
+	c.Set("span_id", span.SpanContext().SpanID().String())
+
+	log.Info().Str("path", c.Request.URL.Path).Str("trace_id", span.SpanContext().TraceID().String()).Str("span_id", span.SpanContext().SpanID().String()).Msg("gin tracing middleware invoked")
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(responseAttributes(c)...)
+
+	// per the HTTP semantic conventions, the status of a server span is only set on 5xx responses; it is left
+	// unset otherwise, so an error recorded by the handler, e.g., by logs.Error, is not overridden.
+	code := otelCodes.Unset
+	if status >= 500 && !hasErrorStatus(span) {
+		code = otelCodes.Error
+	}
+
+	var err error
+	if code == otelCodes.Error && c.Errors.Last() != nil {
+		err = c.Errors.Last()
+	}
+
+	end(span, code, err)
+}
+
+// hasErrorStatus reports whether the status of the span was already set to error, e.g., by logs.Error,
+// whose description is then kept.
+func hasErrorStatus(span trace.Span) bool {
+	ro, ok := span.(sdktrace.ReadOnlySpan)
+	return ok && ro.Status().Code == otelCodes.Error
+}
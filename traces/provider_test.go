@@ -0,0 +1,143 @@
+package traces_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/traces"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fixedIDGenerator struct{}
+
+func (fixedIDGenerator) NewIDs(context.Context) (trace.TraceID, trace.SpanID) {
+	return trace.TraceID{0x01}, trace.SpanID{0x02}
+}
+
+func (fixedIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return trace.SpanID{0x03}
+}
+
+type staticDetector struct{}
+
+func (staticDetector) Detect(context.Context) (*resource.Resource, error) {
+	return resource.NewSchemaless(attribute.String("host.name", "unit-test-host")), nil
+}
+
+func TestNew(t *testing.T) {
+	globalTP := otel.GetTracerProvider()
+	rec := tracetest.NewSpanRecorder()
+
+	p, err := traces.New(
+		traces.WithServiceName("provider_test"),
+		traces.WithServiceVersion("1.2.3"),
+		traces.WithBuildDate("2023-01-01"),
+		traces.WithCommitHash("abcdef"),
+		traces.WithEnvironment("test"),
+		traces.WithResourceAttributes(attribute.String("team", "observability")),
+		traces.WithResourceDetectors(staticDetector{}),
+		traces.WithSpanProcessor(rec),
+		traces.WithIDGenerator(fixedIDGenerator{}),
+	)
+	require.NoError(t, err)
+	defer func() { _ = p.Shutdown(context.Background()) }()
+
+	assert.Same(t, globalTP, otel.GetTracerProvider(), "New must not replace the OTel globals")
+	assert.NotNil(t, p.Tracer())
+	assert.NotNil(t, p.TracerProvider())
+	assert.NotNil(t, p.Propagator())
+
+	_, span, err := p.Start(context.Background(), "provider_span", trace.SpanKindInternal)
+	require.NoError(t, err)
+	span.End()
+
+	_, _, err = p.Start(nil, "nil_ctx", trace.SpanKindInternal)
+	assert.Error(t, err)
+
+	ended := rec.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, trace.TraceID{0x01}, ended[0].SpanContext().TraceID())
+
+	res := ended[0].Resource().Set()
+	for k, want := range map[attribute.Key]string{
+		"service.name":    "provider_test",
+		"service.version": "1.2.3",
+		"buildDate":       "2023-01-01",
+		"commitHash":      "abcdef",
+		"env":             "test",
+		"team":            "observability",
+		"host.name":       "unit-test-host",
+	} {
+		v, ok := res.Value(k)
+		assert.True(t, ok, k)
+		assert.Equal(t, want, v.AsString(), k)
+	}
+	assert.Equal(t, p.Resource(), ended[0].Resource())
+}
+
+func TestNew_BatchSettings(t *testing.T) {
+	exp := newRecordingExporter()
+	p, err := traces.New(
+		traces.WithExporter(exp),
+		traces.WithBatchMaxQueueSize(10),
+		traces.WithBatchMaxExportBatchSize(5),
+		traces.WithBatchTimeout(time.Hour),
+		traces.WithBatchExportTimeout(time.Second),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, span, _ := p.Start(context.Background(), "batched", trace.SpanKindInternal)
+		span.End()
+	}
+	assert.Empty(t, exp.GetSpans(), "spans should wait for the batch timeout")
+
+	require.NoError(t, p.ForceFlush(context.Background()))
+	assert.Len(t, exp.GetSpans(), 3)
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	_, err := traces.New(traces.WithPropagators("bogus"))
+	assert.Error(t, err)
+}
+
+func TestProvider_GinTracingMiddleware(t *testing.T) {
+	defer traces.Reset()
+	rec := tracetest.NewSpanRecorder()
+	p, err := traces.New(traces.WithServiceName("provider_test"), traces.WithSpanProcessor(rec))
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(p.GinTracingMiddleware())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	ended := rec.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "GET /ping", ended[0].Name())
+
+	// package level functions panic until a default is set.
+	traces.Reset()
+	assert.Panics(t, func() { _, _, _ = traces.Start(context.Background(), "no default", trace.SpanKindInternal) })
+
+	traces.SetDefault(p)
+	assert.Same(t, p.TracerProvider(), otel.GetTracerProvider().(*sdktrace.TracerProvider))
+	_, span, err := traces.Start(context.Background(), "default", trace.SpanKindInternal)
+	require.NoError(t, err)
+	traces.End(span, codes.Ok, nil)
+	assert.Len(t, rec.Ended(), 2)
+}
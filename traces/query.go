@@ -0,0 +1,22 @@
+package traces
+
+import "github.com/twistingmercury/monitoring/internal/redact"
+
+// QueryPolicy controls how the url.query and url.path attributes of the server spans are written: query parameters
+// can be dropped or masked, by name or by pattern, and gin path parameters masked. The same policy can be passed
+// to logs.WithQueryPolicy, so logs and traces are redacted alike.
+type QueryPolicy = redact.QueryPolicy
+
+// DefaultQueryPolicy returns the policy used when none is supplied: parameters such as token, password, signature
+// and code, and the parameters whose names contain token, secret, passw, signature or credential, are masked.
+func DefaultQueryPolicy() QueryPolicy {
+	return redact.DefaultQueryPolicy()
+}
+
+// WithQueryPolicy sets the policy applied to the url.query and url.path attributes of the server spans.
+// The default is DefaultQueryPolicy.
+func WithQueryPolicy(p QueryPolicy) Option {
+	return func(c *config) {
+		c.query = p
+	}
+}
@@ -0,0 +1,246 @@
+package traces
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// EnvSampler is the environment variable used to select the sampler.
+	EnvSampler = "OTEL_TRACES_SAMPLER"
+	// EnvSamplerArg is the environment variable used to supply the argument of the sampler.
+	EnvSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
+
+	// DefaultRateLimit is the number of traces per second of the `ratelimiting` samplers selected with
+	// OTEL_TRACES_SAMPLER when OTEL_TRACES_SAMPLER_ARG is not set or is invalid.
+	DefaultRateLimit = 100
+)
+
+// ParentBasedRatio returns a sampler that honors the sampling decision of the parent span, and
+// samples the given fraction of root spans.
+func ParentBasedRatio(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// RouteRule assigns a sampler to the spans of a route. Route is matched against the http.route
+// attribute, or the url.path attribute if there isn't one, using path.Match, so patterns
+// such as "/internal/*" are supported.
+type RouteRule struct {
+	Route   string
+	Sampler sdktrace.Sampler
+}
+
+type routeSampler struct {
+	rules    []RouteRule
+	fallback sdktrace.Sampler
+}
+
+// NewRouteSampler returns a sampler that applies the sampler of the first matching rule, or the
+// fallback if no rule matches. For example, to drop health checks and keep every checkout:
+//
+//	traces.NewRouteSampler(traces.ParentBasedRatio(0.1),
+//		traces.RouteRule{Route: "/health", Sampler: sdktrace.NeverSample()},
+//		traces.RouteRule{Route: "/checkout", Sampler: sdktrace.AlwaysSample()})
+//
+// The child spans of the server spans have no route, so the fallback applies to them: it should honor the
+// decision of the parent, as sdktrace.ParentBased samplers do, otherwise the children of dropped spans are
+// exported without their parent. If fallback is nil, sdktrace.ParentBased(sdktrace.AlwaysSample()) is used.
+func NewRouteSampler(fallback sdktrace.Sampler, rules ...RouteRule) sdktrace.Sampler {
+	if fallback == nil {
+		fallback = sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	return routeSampler{rules: rules, fallback: fallback}
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	var route, urlPath string
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			route = attr.Value.AsString()
+		case semconv.URLPathKey:
+			urlPath = attr.Value.AsString()
+		}
+	}
+	if len(route) == 0 {
+		route = urlPath
+	}
+
+	if len(route) > 0 {
+		for _, rule := range s.rules {
+			if ok, _ := path.Match(rule.Route, route); ok {
+				return rule.Sampler.ShouldSample(p)
+			}
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s routeSampler) Description() string {
+	rules := make([]string, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule.Route+":"+rule.Sampler.Description())
+	}
+	return fmt.Sprintf("RouteSampler{%s;fallback:%s}", strings.Join(rules, ","), s.fallback.Description())
+}
+
+type rateLimitingSampler struct {
+	mu        sync.Mutex
+	perSecond float64
+	balance   float64
+	last      time.Time
+}
+
+// NewRateLimitingSampler returns a sampler that samples at most perSecond traces per second.
+// Bursts of up to perSecond traces are allowed. Only the root spans are rate limited; the spans
+// that have a parent follow its decision, so sampled traces keep all their spans.
+func NewRateLimitingSampler(perSecond float64) sdktrace.Sampler {
+	return &rateLimitingSampler{perSecond: perSecond, balance: perSecond, last: time.Now()}
+}
+
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+	result := sdktrace.SamplingResult{Tracestate: psc.TraceState()}
+	if psc.IsValid() {
+		if psc.IsSampled() {
+			result.Decision = sdktrace.RecordAndSample
+		}
+		return result
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.balance += now.Sub(s.last).Seconds() * s.perSecond
+	if s.balance > s.perSecond {
+		s.balance = s.perSecond
+	}
+	s.last = now
+
+	if s.balance >= 1 {
+		s.balance--
+		result.Decision = sdktrace.RecordAndSample
+	}
+	return result
+}
+
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.perSecond)
+}
+
+// SamplerFromEnv returns the sampler selected by the OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG
+// environment variables. It returns nil if OTEL_TRACES_SAMPLER is not set. In addition to the values
+// defined by the OTel specification, `ratelimiting` and `parentbased_ratelimiting` are supported;
+// their argument is the number of traces per second. As the specification requires, invalid values
+// are logged as a warning and ignored: an unsupported sampler, e.g., `jaeger_remote`, returns nil, so
+// the default sampler is used, and an invalid argument is replaced by the default one, 1.0 for the
+// ratios and DefaultRateLimit for the rate limits.
+func SamplerFromEnv() sdktrace.Sampler {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv(EnvSampler)))
+	if len(name) == 0 {
+		return nil
+	}
+	arg := strings.TrimSpace(os.Getenv(EnvSamplerArg))
+
+	switch name {
+	case "always_on":
+		return sdktrace.AlwaysSample()
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case "traceidratio", "parentbased_traceidratio":
+		ratio := 1.0
+		if len(arg) > 0 {
+			r, err := strconv.ParseFloat(arg, 64)
+			if err == nil && r >= 0 && r <= 1 {
+				ratio = r
+			} else {
+				warnSamplerArg(arg, ratio, "a number between 0 and 1")
+			}
+		}
+		if name == "traceidratio" {
+			return sdktrace.TraceIDRatioBased(ratio)
+		}
+		return ParentBasedRatio(ratio)
+	case "ratelimiting", "parentbased_ratelimiting":
+		perSecond := float64(DefaultRateLimit)
+		if len(arg) > 0 {
+			r, err := strconv.ParseFloat(arg, 64)
+			if err == nil && r > 0 {
+				perSecond = r
+			} else {
+				warnSamplerArg(arg, perSecond, "a number of traces per second greater than 0")
+			}
+		}
+		if name == "ratelimiting" {
+			return NewRateLimitingSampler(perSecond)
+		}
+		return sdktrace.ParentBased(NewRateLimitingSampler(perSecond))
+	default:
+		log.Warn().Str(EnvSampler, name).Msg("unsupported sampler; the default sampler is used")
+		return nil
+	}
+}
+
+func warnSamplerArg(arg string, def float64, valid string) {
+	log.Warn().Str(EnvSamplerArg, arg).Float64("default", def).
+		Msgf("invalid sampler argument; a valid value is %s, the default is used", valid)
+}
+
+// recordErrorsSampler records the spans the wrapped sampler drops, so the decision to export them
+// can be made once they end.
+type recordErrorsSampler struct {
+	sdktrace.Sampler
+}
+
+func (s recordErrorsSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.Sampler.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s recordErrorsSampler) Description() string {
+	return "RecordErrors{" + s.Sampler.Description() + "}"
+}
+
+// errorSpanProcessor forwards sampled spans, and spans that were not sampled but ended with an error status.
+type errorSpanProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+func (p errorSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		if s.Status().Code != otelCodes.Error {
+			return
+		}
+		s = sampledSpan{s}
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// sampledSpan marks a recorded span as sampled so it is exported.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
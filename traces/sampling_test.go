@@ -0,0 +1,228 @@
+package traces_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/traces"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func samplingParams(attrs ...attribute.KeyValue) sdktrace.SamplingParameters {
+	tid, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	return sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: tid, Name: "test", Attributes: attrs}
+}
+
+func TestRouteSampler(t *testing.T) {
+	s := traces.NewRouteSampler(sdktrace.NeverSample(),
+		traces.RouteRule{Route: "/health", Sampler: sdktrace.NeverSample()},
+		traces.RouteRule{Route: "/checkout", Sampler: sdktrace.AlwaysSample()},
+		traces.RouteRule{Route: "/internal/*", Sampler: sdktrace.AlwaysSample()},
+	)
+
+	assert.Equal(t, sdktrace.Drop, s.ShouldSample(samplingParams(attribute.String("http.route", "/health"))).Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, s.ShouldSample(samplingParams(attribute.String("http.route", "/checkout"))).Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, s.ShouldSample(samplingParams(attribute.String("url.path", "/internal/x"))).Decision)
+	assert.Equal(t, sdktrace.Drop, s.ShouldSample(samplingParams(attribute.String("http.route", "/other"))).Decision)
+	assert.Equal(t, sdktrace.Drop, s.ShouldSample(samplingParams()).Decision)
+	assert.Contains(t, s.Description(), "/checkout:AlwaysOnSampler")
+}
+
+func TestRateLimitingSampler(t *testing.T) {
+	s := traces.NewRateLimitingSampler(5)
+
+	sampled := 0
+	for i := 0; i < 100; i++ {
+		if s.ShouldSample(samplingParams()).Decision == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	assert.Equal(t, 5, sampled)
+	assert.Equal(t, "RateLimitingSampler{5}", s.Description())
+}
+
+func TestInitialize_RateLimitingTraces(t *testing.T) {
+	t.Setenv(traces.EnvSampler, "ratelimiting")
+	t.Setenv(traces.EnvSamplerArg, "2")
+
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	// the limit is in traces: the children of sampled traces are sampled, and don't spend tokens.
+	for i := 0; i < 5; i++ {
+		ctx, root, _ := traces.Start(context.Background(), "root", trace.SpanKindServer)
+		for j := 0; j < 3; j++ {
+			_, child, _ := traces.Start(ctx, "child", trace.SpanKindInternal)
+			traces.End(child, codes.Unset, nil)
+		}
+		traces.End(root, codes.Unset, nil)
+	}
+	require.NoError(t, shutdown(context.Background()))
+
+	perTrace := make(map[trace.TraceID]int)
+	for _, s := range exp.GetSpans() {
+		perTrace[s.SpanContext.TraceID()]++
+	}
+	assert.Len(t, perTrace, 2)
+	for _, n := range perTrace {
+		assert.Equal(t, 4, n)
+	}
+}
+
+func TestSamplerFromEnv(t *testing.T) {
+	tests := []struct {
+		sampler     string
+		arg         string
+		description string
+	}{
+		{"", "", ""},
+		{"always_on", "", "AlwaysOnSampler"},
+		{"always_off", "", "AlwaysOffSampler"},
+		{"traceidratio", "0.25", "TraceIDRatioBased{0.25}"},
+		{"parentbased_traceidratio", "0.5", "ParentBased{root:TraceIDRatioBased{0.5}"},
+		{"parentbased_always_on", "", "ParentBased{root:AlwaysOnSampler"},
+		{"parentbased_always_off", "", "ParentBased{root:AlwaysOffSampler"},
+		{"ratelimiting", "10", "RateLimitingSampler{10}"},
+		{"parentbased_ratelimiting", "10", "ParentBased{root:RateLimitingSampler{10}"},
+		// invalid arguments are replaced by the default ones.
+		{"traceidratio", "2", "AlwaysOnSampler"},
+		{"parentbased_traceidratio", "half", "ParentBased{root:AlwaysOnSampler"},
+		{"ratelimiting", "", "RateLimitingSampler{100}"},
+		{"ratelimiting", "-3", "RateLimitingSampler{100}"},
+		// unsupported samplers are ignored.
+		{"bogus", "", ""},
+		{"jaeger_remote", "endpoint=http://localhost:14250", ""},
+		{"parentbased_jaeger_remote", "", ""},
+		{"xray", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sampler+"_"+tt.arg, func(t *testing.T) {
+			t.Setenv(traces.EnvSampler, tt.sampler)
+			t.Setenv(traces.EnvSamplerArg, tt.arg)
+
+			s := traces.SamplerFromEnv()
+			if len(tt.description) == 0 {
+				assert.Nil(t, s)
+			} else {
+				assert.Contains(t, s.Description(), tt.description)
+			}
+
+			_, err := traces.Initialize(traces.NewNoopExporter(), "test_service", "test_version", "2023-01-01", "123456", "test")
+			assert.NoError(t, err, "invalid values must not prevent the start")
+			traces.Reset()
+		})
+	}
+}
+
+func TestInitialize_SamplerFromEnv(t *testing.T) {
+	t.Setenv(traces.EnvSampler, "always_off")
+
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	_, span, _ := traces.Start(context.Background(), "dropped", trace.SpanKindInternal)
+	traces.End(span, codes.Ok, nil)
+	require.NoError(t, shutdown(context.Background()))
+	assert.Empty(t, exp.GetSpans())
+}
+
+func TestInitialize_RouteSampling(t *testing.T) {
+	exp := newRecordingExporter()
+	sampler := traces.NewRouteSampler(traces.ParentBasedRatio(1),
+		traces.RouteRule{Route: "/health", Sampler: sdktrace.NeverSample()})
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithSampler(sampler))
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/checkout", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	require.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /checkout", spans[0].Name)
+}
+
+func TestInitialize_UnsupportedSamplerFromEnv(t *testing.T) {
+	t.Setenv(traces.EnvSampler, "jaeger_remote")
+
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	_, span, _ := traces.Start(context.Background(), "sampled", trace.SpanKindInternal)
+	traces.End(span, codes.Ok, nil)
+	require.NoError(t, shutdown(context.Background()))
+	assert.Len(t, exp.GetSpans(), 1)
+}
+
+func TestInitialize_RouteSamplingChildSpans(t *testing.T) {
+	exp := newRecordingExporter()
+	sampler := traces.NewRouteSampler(nil,
+		traces.RouteRule{Route: "/health", Sampler: sdktrace.NeverSample()})
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithSampler(sampler))
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	handler := func(c *gin.Context) {
+		_, child, _ := traces.Start(c.Request.Context(), "db ping "+c.FullPath(), trace.SpanKindClient)
+		traces.End(child, codes.Ok, nil)
+		c.Status(http.StatusOK)
+	}
+	r.GET("/health", handler)
+	r.GET("/checkout", handler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	require.NoError(t, shutdown(context.Background()))
+
+	var names []string
+	for _, s := range exp.GetSpans() {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"GET /checkout", "db ping /checkout"}, names)
+}
+
+func TestInitialize_ErrorSampling(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithSampler(sdktrace.NeverSample()),
+		traces.WithErrorSampling())
+	require.NoError(t, err)
+	defer traces.Reset()
+
+	_, ok, _ := traces.Start(context.Background(), "ok", trace.SpanKindInternal)
+	traces.End(ok, codes.Ok, nil)
+	_, failed, _ := traces.Start(context.Background(), "failed", trace.SpanKindInternal)
+	traces.End(failed, codes.Error, errors.New("test error"))
+	require.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "failed", spans[0].Name)
+	assert.True(t, spans[0].SpanContext.IsSampled())
+}
@@ -4,31 +4,39 @@ package traces
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	otelCodes "go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	notInitializedError = "traces.Initialize() has not been invoked"
-)
 
-var (
-	isInitialized bool
-	tp            *sdktrace.TracerProvider
-	tracer        trace.Tracer
-	commonAttrs   []attribute.KeyValue
+	// DefaultUnmatchedRouteName is the span name used for requests that don't match a gin route.
+	DefaultUnmatchedRouteName = "unmatched route"
 )
 
+var current atomic.Pointer[Provider]
+
+// load returns the default Provider and panics if Initialize has not been invoked.
+func load() *Provider {
+	p := current.Load()
+	if p == nil {
+		panic(notInitializedError)
+	}
+	return p
+}
+
 type noopExporter struct{}
 
 func (n noopExporter) ExportSpans(_ context.Context, _ []sdktrace.ReadOnlySpan) error {
@@ -49,37 +57,25 @@ func NewHTTPExporter(ctx context.Context, url string, opts ...otlptracehttp.Opti
 	return otlptracehttp.New(ctx, opts...)
 }
 
-// Initialize initializes the tracing system.
-func Initialize(exporter sdktrace.SpanExporter, ver, apiName, buildDate, commitHash, env string) (shutdown func(context.Context) error, err error) {
-	isInitialized = false
-	ctx := context.Background()
-
-	// all traces will share these attributes
-	commonAttrs = []attribute.KeyValue{
-		semconv.ServiceNameKey.String(apiName),
-		semconv.ServiceVersionKey.String(ver),
-		{Key: "buildDate", Value: attribute.StringValue(buildDate)},
-		{Key: "commitHash", Value: attribute.StringValue(commitHash)},
-		{Key: "env", Value: attribute.StringValue(env)},
+// Initialize initializes the tracing system. It creates a Provider that exports to exporter, and makes it the
+// default; see New and SetDefault. The options are applied after the positional arguments, so they take precedence.
+func Initialize(exporter sdktrace.SpanExporter, ver, apiName, buildDate, commitHash, env string, opts ...Option) (shutdown func(context.Context) error, err error) {
+	opts = append([]Option{
+		WithExporter(exporter),
+		WithServiceName(apiName),
+		WithServiceVersion(ver),
+		WithBuildDate(buildDate),
+		WithCommitHash(commitHash),
+		WithEnvironment(env),
+	}, opts...)
+
+	p, err := New(opts...)
+	if err != nil {
+		return
 	}
-	res, _ := resource.New(ctx, resource.WithAttributes(commonAttrs...))
 
-	bsp := sdktrace.NewBatchSpanProcessor(exporter)
-	tp = sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
-
-	otel.SetTracerProvider(tp)
-
-	// set global propagator to trace context (the default is no-op).
-	cp := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
-	otel.SetTextMapPropagator(cp)
-	tracer = tp.Tracer(apiName)
-
-	isInitialized = true
-	shutdown = tp.Shutdown
+	SetDefault(p)
+	shutdown = p.Shutdown
 	return
 }
 
@@ -92,25 +88,7 @@ func Initialize(exporter sdktrace.SpanExporter, ver, apiName, buildDate, commitH
 // out: span: The span.
 // out: err: The error if the context is nil.
 func Start(ctx context.Context, spanName string, kind trace.SpanKind, attributes ...attribute.KeyValue) (spanCtx context.Context, span trace.Span, err error) {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
-
-	if ctx == nil {
-		err = fmt.Errorf("context is nil")
-		return
-	}
-
-	if len(attributes) > 0 {
-		commonAttrs = append(commonAttrs, attributes...)
-	}
-
-	spanCtx, span = tracer.Start(
-		ctx,
-		spanName,
-		trace.WithSpanKind(kind),
-		trace.WithAttributes(commonAttrs...))
-	return
+	return load().Start(ctx, spanName, kind, attributes...)
 }
 
 // End ends the span with the supplied status and error. This is merely a convenience function that wraps the the trace.Span SetStatus and End functions of the span.
@@ -118,10 +96,11 @@ func Start(ctx context.Context, spanName string, kind trace.SpanKind, attributes
 // in: status: The status code. 0 is "unset", 1 is "error", and 2 is "ok".
 // in: err: The error. Can be nil. If the status is "error", the error the error is used as the description for the status.
 func End(span trace.Span, status otelCodes.Code, err error) {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
-	
+	load()
+	end(span, status, err)
+}
+
+func end(span trace.Span, status otelCodes.Code, err error) {
 	msg := ""
 	if err != nil {
 		msg = fmt.Sprintf("%v", err)
@@ -141,34 +120,14 @@ func End(span trace.Span, status otelCodes.Code, err error) {
 //
 // Deprecated: Use traces.Start(context.Context, string, trace.SpanKind, ...attribute.KeyValue) instead. This function will be removed in v2.0.0.
 func NewSpan(traceCtx context.Context, spanName string, kind trace.SpanKind, attributes ...attribute.KeyValue) (spanCtx context.Context, span trace.Span, err error) {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
-
-	if traceCtx == nil {
-		err = fmt.Errorf("context is nil")
-		return
-	}
-
-	if len(attributes) > 0 {
-		commonAttrs = append(commonAttrs, attributes...)
-	}
-
-	spanCtx, span = tracer.Start(
-		traceCtx,
-		spanName,
-		trace.WithSpanKind(kind),
-		trace.WithAttributes(commonAttrs...))
-	return
+	return Start(traceCtx, spanName, kind, attributes...)
 }
 
 // EndOK ends the span with a status of "ok".
 //
 // Deprecated: use traces.End(trace.Span, otel.Codes, string) instead. This function will be removed in v2.0.0.
 func EndOK(span trace.Span) {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
+	load()
 	span.SetStatus(otelCodes.Ok, "ok")
 	span.End()
 }
@@ -177,56 +136,119 @@ func EndOK(span trace.Span) {
 //
 // Deprecated: use traces.End(trace.Span, otel.Codes, string) instead. This function will be removed in v2.0.0.
 func EndError(span trace.Span, err error) {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
+	load()
 	span.RecordError(err)
 	span.SetStatus(otelCodes.Error, "error")
 	span.End()
 }
 
+// GinTracingMiddleware starts a server span for each request. The caller's span context is extracted
+// from the request headers using the configured propagators, so the span continues the caller's trace.
+// The span is stored in the context of c.Request, so spans started from c.Request.Context() are its children.
+//
+// Spans are named using the route template, e.g., "GET /person/:id", rather than the raw path, and carry the
+// OTel HTTP server semantic convention attributes.
 func GinTracingMiddleware() gin.HandlerFunc {
-	if !isInitialized {
-		panic(notInitializedError)
-	}
+	load()
 
 	return func(c *gin.Context) {
-		_, span := tracer.Start(
-			c.Request.Context(),
-			c.Request.URL.Path,
-			trace.WithSpanKind(trace.SpanKindServer),
-			trace.WithAttributes(commonAttrs...))
+		load().serve(c)
+	}
+}
 
-		c.Set("trace_id", span.SpanContext().TraceID().String())
-		c.Set("span_id", span.SpanContext().SpanID().String())
+// spanName returns "<METHOD> <route template>", or the unmatched route name if no route matched.
+func spanName(c *gin.Context, unmatchedName string) string {
+	route := c.FullPath()
+	if len(route) == 0 {
+		return unmatchedName
+	}
+	return c.Request.Method + " " + route
+}
 
-		log.Info().Str("path", c.Request.URL.Path).Str("trace_id", span.SpanContext().TraceID().String()).Str("span_id", span.SpanContext().SpanID().String()).Msg("gin tracing middleware invoked")
+// requestAttributes returns the semantic convention attributes known when the request starts.
+// They are passed to tracer.Start so samplers can use them. The query policy is applied to url.path and url.query.
+func requestAttributes(c *gin.Context, query QueryPolicy) []attribute.KeyValue {
+	req := c.Request
+	attrs := make([]attribute.KeyValue, 0, 12)
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		attrs = append(attrs, semconv.HTTPRequestMethodKey.String(req.Method))
+	default:
+		attrs = append(attrs,
+			semconv.HTTPRequestMethodKey.String("_OTHER"),
+			semconv.HTTPRequestMethodOriginal(req.Method))
+	}
 
-		c.Next()
+	if route := c.FullPath(); len(route) > 0 {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
 
-		status := c.Writer.Status()
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	attrs = append(attrs, semconv.URLScheme(scheme), semconv.URLPath(query.Path(req.URL.Path, c.FullPath())))
+	if rQuery := req.URL.RawQuery; len(rQuery) > 0 {
+		attrs = append(attrs, semconv.URLQuery(query.Query(rQuery)))
+	}
 
-		var code otelCodes.Code
-		switch {
-		case status >= 200 && status < 300:
-			code = otelCodes.Ok
-		case status >= 300 && status < 400:
-			code = otelCodes.Unset
-		case status >= 500:
-			code = otelCodes.Error
-		}
+	host, port := splitHostPort(req.Host)
+	if len(host) > 0 {
+		attrs = append(attrs, semconv.ServerAddress(host))
+	}
+	if port > 0 {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+
+	if ip := c.ClientIP(); len(ip) > 0 {
+		attrs = append(attrs, semconv.ClientAddress(ip))
+	}
+
+	if ua := req.UserAgent(); len(ua) > 0 {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+
+	if req.ContentLength > 0 {
+		attrs = append(attrs, semconv.HTTPRequestBodySize(int(req.ContentLength)))
+	}
+	return attrs
+}
 
-		End(span, code, nil)
+// responseAttributes returns the semantic convention attributes known once the request has been handled.
+func responseAttributes(c *gin.Context) []attribute.KeyValue {
+	status := c.Writer.Status()
+	attrs := []attribute.KeyValue{semconv.HTTPResponseStatusCode(status)}
+
+	if size := c.Writer.Size(); size > 0 {
+		attrs = append(attrs, semconv.HTTPResponseBodySize(size))
+	}
+
+	switch {
+	case status >= 500:
+		attrs = append(attrs, semconv.ErrorTypeKey.String(strconv.Itoa(status)))
+	case c.Errors.Last() != nil:
+		attrs = append(attrs, semconv.ErrorTypeKey.String(fmt.Sprintf("%T", c.Errors.Last().Err)))
+	}
+	return attrs
+}
+
+// splitHostPort splits the host header into host and port. The port is 0 if not present.
+func splitHostPort(hostport string) (host string, port int) {
+	host, p, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport, 0
 	}
+	port, _ = strconv.Atoi(p)
+	return
 }
 
 func reset() {
-	if tp == nil {
+	p := current.Swap(nil)
+	if p == nil {
 		return
 	}
-	_ = tp.Shutdown(context.Background())
-	tp = nil
-	tracer = nil
-	isInitialized = false
+	_ = p.Shutdown(context.Background())
 	log.Debug().Msg("tracer reset")
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"errors"
@@ -16,10 +18,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/twistingmercury/monitoring/logs"
 	"github.com/twistingmercury/monitoring/traces"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -330,3 +334,194 @@ func traceHttpHandler() http.HandlerFunc {
 		_, _ = rw.Write([]byte(`test`))
 	}
 }
+
+func TestGinTracingMiddleware_SpanInRequestContext(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	assert.NoError(t, err)
+	defer traces.Reset()
+
+	buf := &bytes.Buffer{}
+	logs.Initialize(zerolog.DebugLevel, "test_version", "test_service", "2023-01-01", "123456", "test", buf, logs.WithCorrelation(logs.OTelCorrelation()))
+
+	var serverSC, childSC trace.SpanContext
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/", func(c *gin.Context) {
+		serverSC = trace.SpanContextFromContext(c.Request.Context())
+		logs.Info(c.Request.Context(), "in handler", nil)
+
+		_, span, err := traces.Start(c.Request.Context(), "child", trace.SpanKindInternal)
+		assert.NoError(t, err)
+		childSC = span.SpanContext()
+		traces.End(span, codes.Ok, nil)
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, shutdown(context.Background()))
+
+	assert.True(t, serverSC.IsValid())
+	assert.Equal(t, serverSC.TraceID(), childSC.TraceID())
+
+	var child tracetest.SpanStub
+	for _, s := range exp.GetSpans() {
+		if s.Name == "child" {
+			child = s
+		}
+	}
+	assert.Equal(t, serverSC.SpanID(), child.Parent.SpanID())
+
+	le := make(map[string]any)
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &le))
+	assert.Equal(t, serverSC.TraceID().String(), le["trace_id"])
+	assert.Equal(t, serverSC.SpanID().String(), le["span_id"])
+}
+
+func TestGinTracingMiddleware_RouteTemplateAndAttributes(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithUnmatchedRouteName("no route"))
+	assert.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.POST("/person/:id", func(c *gin.Context) {
+		_ = c.Error(errors.New("boom"))
+		c.String(http.StatusInternalServerError, "failed")
+	})
+
+	for _, path := range []string{"/person/123", "/person/456"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com:8080"+path, strings.NewReader("hello"))
+		req.Header.Set("User-Agent", "unit-test")
+		req.RemoteAddr = "10.1.2.3:5555"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	assert.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "POST /person/:id", spans[0].Name)
+	assert.Equal(t, "POST /person/:id", spans[1].Name)
+	assert.Equal(t, "no route", spans[2].Name)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "/person/:id", attrs["http.route"].AsString())
+	assert.Equal(t, "POST", attrs["http.request.method"].AsString())
+	assert.Equal(t, "http", attrs["url.scheme"].AsString())
+	assert.Equal(t, "example.com", attrs["server.address"].AsString())
+	assert.Equal(t, int64(8080), attrs["server.port"].AsInt64())
+	assert.Equal(t, "10.1.2.3", attrs["client.address"].AsString())
+	assert.Equal(t, "unit-test", attrs["user_agent.original"].AsString())
+	assert.Equal(t, int64(500), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(5), attrs["http.request.body.size"].AsInt64())
+	assert.Equal(t, int64(6), attrs["http.response.body.size"].AsInt64())
+	assert.Equal(t, "500", attrs["error.type"].AsString())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+
+	for _, kv := range spans[2].Attributes {
+		assert.NotEqual(t, attribute.Key("http.route"), kv.Key)
+	}
+}
+
+func TestGinTracingMiddleware_QueryPolicy(t *testing.T) {
+	exp := newRecordingExporter()
+	p := traces.DefaultQueryPolicy()
+	p.PathParams = []string{"email"}
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithQueryPolicy(p))
+	assert.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/users/:email", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/jane@example.com?token=s3cr3t&page=2", nil))
+	assert.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	assert.Len(t, spans, 1)
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "/users/****", attrs["url.path"].AsString())
+	assert.Equal(t, "token=****&page=2", attrs["url.query"].AsString())
+	assert.Equal(t, "/users/:email", attrs["http.route"].AsString())
+}
+
+func TestStart_AttributesScopedToSpan(t *testing.T) {
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test")
+	assert.NoError(t, err)
+	defer traces.Reset()
+
+	_, first, _ := traces.Start(context.Background(), "first", trace.SpanKindInternal, attribute.String("only", "first"))
+	traces.End(first, codes.Ok, nil)
+	_, second, _ := traces.Start(context.Background(), "second", trace.SpanKindInternal)
+	traces.End(second, codes.Ok, nil)
+	assert.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("only", "first")}, spans[0].Attributes)
+	assert.Empty(t, spans[1].Attributes)
+
+	svc, ok := spans[1].Resource.Set().Value("service.name")
+	assert.True(t, ok)
+	assert.Equal(t, "test_version", svc.AsString())
+}
+
+func TestStart_ConcurrentStress(t *testing.T) {
+	const workers, iterations = 16, 50
+
+	exp := newRecordingExporter()
+	shutdown, err := traces.Initialize(exp, "test_service", "test_version", "2023-01-01", "123456", "test",
+		traces.WithBatchMaxQueueSize(workers*iterations*3))
+	assert.NoError(t, err)
+	defer traces.Reset()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/:id", func(c *gin.Context) {
+		_, span, _ := traces.Start(c.Request.Context(), "handler", trace.SpanKindInternal, attribute.String("id", c.Param("id")))
+		traces.End(span, codes.Ok, nil)
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				_, span, err := traces.Start(context.Background(), "worker", trace.SpanKindInternal, attribute.Int("worker", w), attribute.Int("i", i))
+				assert.NoError(t, err)
+				traces.End(span, codes.Ok, nil)
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%d", i), nil))
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.NoError(t, shutdown(context.Background()))
+
+	spans := exp.GetSpans()
+	assert.Len(t, spans, workers*iterations*3)
+	for _, s := range spans {
+		switch s.Name {
+		case "worker":
+			assert.Len(t, s.Attributes, 2)
+		case "handler":
+			assert.Len(t, s.Attributes, 1)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Package tracetest provides an in-memory span recorder and assertion helpers for testing code that is
+// instrumented with the traces package.
+package tracetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/twistingmercury/monitoring/traces"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	sdktracetest "go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span is a snapshot of an ended span.
+type Span = sdktracetest.SpanStub
+
+// Spans is a list of ended spans.
+type Spans = sdktracetest.SpanStubs
+
+// Recorder records the spans ended by the traces package.
+type Recorder struct {
+	provider *traces.Provider
+	exporter *sdktracetest.InMemoryExporter
+}
+
+// Initialize initializes the tracing system with an in-memory exporter and a synchronous span processor,
+// so spans can be inspected as soon as they end. Every span is sampled unless a sampler is supplied in opts.
+// The provider is made the default used by the package level functions of traces, and is shut down
+// when the test completes; the previous default and the OTel globals are then restored.
+func Initialize(t testing.TB, opts ...traces.Option) *Recorder {
+	t.Helper()
+
+	exp := sdktracetest.NewInMemoryExporter()
+	opts = append([]traces.Option{
+		traces.WithServiceName(t.Name()),
+		traces.WithSampler(sdktrace.AlwaysSample()),
+		traces.WithSpanProcessor(sdktrace.NewSimpleSpanProcessor(exp)),
+	}, opts...)
+
+	p, err := traces.New(opts...)
+	if err != nil {
+		t.Fatalf("failed to initialize tracing: %v", err)
+	}
+	prev, prevTP, prevProp := traces.Default(), otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	traces.SetDefault(p)
+
+	r := &Recorder{provider: p, exporter: exp}
+	t.Cleanup(func() {
+		_ = p.Shutdown(context.Background())
+		traces.SetDefault(prev)
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return r
+}
+
+// Provider returns the provider the recorder is attached to.
+func (r *Recorder) Provider() *traces.Provider {
+	return r.provider
+}
+
+// Spans returns the ended spans in the order they ended.
+func (r *Recorder) Spans() Spans {
+	return r.exporter.GetSpans()
+}
+
+// Reset discards the recorded spans.
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}
+
+// SpansByName returns the ended spans with the given name.
+func (r *Recorder) SpansByName(name string) Spans {
+	return r.filter(func(s Span) bool { return s.Name == name })
+}
+
+// SpansByTraceID returns the ended spans that belong to the trace.
+func (r *Recorder) SpansByTraceID(traceID trace.TraceID) Spans {
+	return r.filter(func(s Span) bool { return s.SpanContext.TraceID() == traceID })
+}
+
+// ChildrenOf returns the ended spans whose parent is span.
+func (r *Recorder) ChildrenOf(span Span) Spans {
+	return r.filter(func(s Span) bool {
+		return s.Parent.TraceID() == span.SpanContext.TraceID() && s.Parent.SpanID() == span.SpanContext.SpanID()
+	})
+}
+
+// AssertSpan asserts that an ended span has the name and satisfies all matchers, and returns the first such span.
+// On failure, the reasons and the recorded span tree are reported.
+func (r *Recorder) AssertSpan(t testing.TB, name string, matchers ...Matcher) Span {
+	t.Helper()
+
+	candidates := r.SpansByName(name)
+	if len(candidates) == 0 {
+		t.Errorf("no span named %q was recorded\n%s", name, r.Tree())
+		return Span{}
+	}
+
+	var reasons []string
+	for _, s := range candidates {
+		failed := mismatches(s, matchers)
+		if len(failed) == 0 {
+			return s
+		}
+		reasons = append(reasons, fmt.Sprintf("  %s: %s", describe(s), strings.Join(failed, "; ")))
+	}
+
+	t.Errorf("no span named %q satisfies the matchers:\n%s\n%s", name, strings.Join(reasons, "\n"), r.Tree())
+	return Span{}
+}
+
+// AssertNoSpan asserts that no ended span has the name.
+func (r *Recorder) AssertNoSpan(t testing.TB, name string) {
+	t.Helper()
+	if len(r.SpansByName(name)) > 0 {
+		t.Errorf("unexpected span named %q was recorded\n%s", name, r.Tree())
+	}
+}
+
+// Tree returns the ended spans as a tree, grouped by trace, for use in failure messages. For example:
+//
+//	trace 0af7651916cd43dd8448eb211c80319c
+//	└── GET /person/:id [server] Ok
+//	    └── select person [client] Error: not found
+func (r *Recorder) Tree() string {
+	spans := r.Spans()
+	if len(spans) == 0 {
+		return "(no spans recorded)"
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTime.Before(spans[j].StartTime) })
+
+	ended := make(map[trace.SpanID]bool, len(spans))
+	for _, s := range spans {
+		ended[s.SpanContext.SpanID()] = true
+	}
+
+	var traceIDs []trace.TraceID
+	roots := make(map[trace.TraceID]Spans)
+	children := make(map[trace.SpanID]Spans)
+	for _, s := range spans {
+		tid := s.SpanContext.TraceID()
+		if _, ok := roots[tid]; !ok {
+			traceIDs = append(traceIDs, tid)
+			roots[tid] = nil
+		}
+		if s.Parent.IsValid() && ended[s.Parent.SpanID()] {
+			children[s.Parent.SpanID()] = append(children[s.Parent.SpanID()], s)
+			continue
+		}
+		roots[tid] = append(roots[tid], s)
+	}
+
+	sb := &strings.Builder{}
+	for _, tid := range traceIDs {
+		fmt.Fprintf(sb, "trace %s\n", tid)
+		writeTree(sb, roots[tid], children, "")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func writeTree(sb *strings.Builder, spans Spans, children map[trace.SpanID]Spans, indent string) {
+	for i, s := range spans {
+		branch, next := "├── ", "│   "
+		if i == len(spans)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(sb, "%s%s%s\n", indent, branch, describe(s))
+		writeTree(sb, children[s.SpanContext.SpanID()], children, indent+next)
+	}
+}
+
+func describe(s Span) string {
+	d := fmt.Sprintf("%s [%s] %s", s.Name, s.SpanKind, s.Status.Code)
+	if len(s.Status.Description) > 0 {
+		d += ": " + s.Status.Description
+	}
+	return d
+}
+
+func (r *Recorder) filter(keep func(Span) bool) Spans {
+	var out Spans
+	for _, s := range r.Spans() {
+		if keep(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Matcher checks a property of a span. It returns a description of the mismatch, or an empty string
+// if the span matches.
+type Matcher func(s Span) string
+
+func mismatches(s Span, matchers []Matcher) (failed []string) {
+	for _, m := range matchers {
+		if reason := m(s); len(reason) > 0 {
+			failed = append(failed, reason)
+		}
+	}
+	return
+}
+
+// HasAttribute matches spans that have the attribute with the same value.
+func HasAttribute(kv attribute.KeyValue) Matcher {
+	return func(s Span) string {
+		for _, attr := range s.Attributes {
+			if attr.Key != kv.Key {
+				continue
+			}
+			if attr.Value == kv.Value {
+				return ""
+			}
+			return fmt.Sprintf("attribute %s is %q, want %q", kv.Key, attr.Value.Emit(), kv.Value.Emit())
+		}
+		return fmt.Sprintf("attribute %s is missing", kv.Key)
+	}
+}
+
+// HasAttributeKey matches spans that have the attribute, regardless of its value.
+func HasAttributeKey(key attribute.Key) Matcher {
+	return func(s Span) string {
+		for _, attr := range s.Attributes {
+			if attr.Key == key {
+				return ""
+			}
+		}
+		return fmt.Sprintf("attribute %s is missing", key)
+	}
+}
+
+// HasStatus matches spans with the status code.
+func HasStatus(code codes.Code) Matcher {
+	return func(s Span) string {
+		if s.Status.Code == code {
+			return ""
+		}
+		return fmt.Sprintf("status is %s, want %s", s.Status.Code, code)
+	}
+}
+
+// HasKind matches spans of the kind.
+func HasKind(kind trace.SpanKind) Matcher {
+	return func(s Span) string {
+		if s.SpanKind == kind {
+			return ""
+		}
+		return fmt.Sprintf("kind is %s, want %s", s.SpanKind, kind)
+	}
+}
+
+// HasEvent matches spans that have an event with the name. If attributes are supplied, the event must have them too.
+func HasEvent(name string, attrs ...attribute.KeyValue) Matcher {
+	return func(s Span) string {
+		for _, e := range s.Events {
+			if e.Name != name {
+				continue
+			}
+			if len(mismatches(Span{Attributes: e.Attributes}, attributeMatchers(attrs))) == 0 {
+				return ""
+			}
+		}
+		return fmt.Sprintf("event %q with attributes %v is missing", name, attrs)
+	}
+}
+
+// HasParent matches spans whose parent is parent.
+func HasParent(parent Span) Matcher {
+	return func(s Span) string {
+		if s.Parent.SpanID() == parent.SpanContext.SpanID() && s.Parent.TraceID() == parent.SpanContext.TraceID() {
+			return ""
+		}
+		return fmt.Sprintf("parent is %s, want %s", s.Parent.SpanID(), parent.SpanContext.SpanID())
+	}
+}
+
+func attributeMatchers(attrs []attribute.KeyValue) []Matcher {
+	matchers := make([]Matcher, 0, len(attrs))
+	for _, kv := range attrs {
+		matchers = append(matchers, HasAttribute(kv))
+	}
+	return matchers
+}
@@ -0,0 +1,139 @@
+package tracetest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twistingmercury/monitoring/traces"
+	"github.com/twistingmercury/monitoring/traces/tracetest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeT captures the failures reported by the assertion helpers.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func handledRequest(t *testing.T) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(traces.GinTracingMiddleware())
+	r.GET("/person/:id", func(c *gin.Context) {
+		ctx, span, err := traces.Start(c.Request.Context(), "select person", trace.SpanKindClient, attribute.String("db.system", "postgresql"))
+		require.NoError(t, err)
+		span.AddEvent("query", trace.WithAttributes(attribute.Int("rows", 0)))
+		_, cache, _ := traces.Start(ctx, "cache lookup", trace.SpanKindInternal)
+		traces.End(cache, codes.Ok, nil)
+		traces.End(span, codes.Error, errors.New("not found"))
+		c.Status(http.StatusNotFound)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/person/42", nil))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	handledRequest(t)
+
+	require.Len(t, rec.Spans(), 3)
+
+	server := rec.AssertSpan(t, "GET /person/:id",
+		tracetest.HasKind(trace.SpanKindServer),
+		tracetest.HasAttribute(attribute.String("http.route", "/person/:id")),
+		tracetest.HasAttributeKey("http.response.status_code"))
+
+	db := rec.AssertSpan(t, "select person",
+		tracetest.HasParent(server),
+		tracetest.HasStatus(codes.Error),
+		tracetest.HasEvent("query", attribute.Int("rows", 0)))
+
+	rec.AssertSpan(t, "cache lookup", tracetest.HasParent(db), tracetest.HasStatus(codes.Ok))
+	rec.AssertNoSpan(t, "unrelated")
+
+	assert.Len(t, rec.SpansByTraceID(server.SpanContext.TraceID()), 3)
+	assert.Len(t, rec.ChildrenOf(server), 1)
+	assert.Equal(t, "select person", rec.ChildrenOf(server)[0].Name)
+
+	expected := fmt.Sprintf(`trace %s
+└── GET /person/:id [server] Unset
+    └── select person [client] Error: not found
+        └── cache lookup [internal] Ok`, server.SpanContext.TraceID())
+	assert.Equal(t, expected, rec.Tree())
+
+	rec.Reset()
+	assert.Empty(t, rec.Spans())
+	assert.Equal(t, "(no spans recorded)", rec.Tree())
+}
+
+func TestRecorder_Failures(t *testing.T) {
+	rec := tracetest.Initialize(t)
+	handledRequest(t)
+
+	ft := &fakeT{TB: t}
+	rec.AssertSpan(ft, "missing")
+	rec.AssertSpan(ft, "select person",
+		tracetest.HasStatus(codes.Ok),
+		tracetest.HasAttribute(attribute.String("db.system", "mysql")),
+		tracetest.HasAttributeKey("db.statement"),
+		tracetest.HasKind(trace.SpanKindServer),
+		tracetest.HasEvent("retry"))
+	rec.AssertNoSpan(ft, "cache lookup")
+
+	require.Len(t, ft.errors, 3)
+	assert.Contains(t, ft.errors[0], `no span named "missing" was recorded`)
+	assert.Contains(t, ft.errors[0], "GET /person/:id [server]")
+	assert.Contains(t, ft.errors[1], "status is Error, want Ok")
+	assert.Contains(t, ft.errors[1], `attribute db.system is "postgresql", want "mysql"`)
+	assert.Contains(t, ft.errors[1], "attribute db.statement is missing")
+	assert.Contains(t, ft.errors[1], "kind is client, want server")
+	assert.Contains(t, ft.errors[1], `event "retry"`)
+	assert.Contains(t, ft.errors[2], `unexpected span named "cache lookup"`)
+}
+
+func TestInitialize_SetsDefault(t *testing.T) {
+	rec := tracetest.Initialize(t, traces.WithServiceName("custom"))
+
+	_, span, err := traces.Start(context.Background(), "standalone", trace.SpanKindInternal)
+	require.NoError(t, err)
+	span.End()
+
+	s := rec.AssertSpan(t, "standalone")
+	v, _ := s.Resource.Set().Value("service.name")
+	assert.Equal(t, "custom", v.AsString())
+	assert.Equal(t, rec.Provider().Resource(), s.Resource)
+}
+
+func TestInitialize_RestoresDefault(t *testing.T) {
+	outer := tracetest.Initialize(t)
+	tp := otel.GetTracerProvider()
+
+	t.Run("inner", func(t *testing.T) {
+		inner := tracetest.Initialize(t)
+		assert.Equal(t, inner.Provider(), traces.Default())
+	})
+
+	assert.Equal(t, outer.Provider(), traces.Default())
+	assert.Equal(t, tp, otel.GetTracerProvider())
+
+	_, span, err := traces.Start(context.Background(), "after inner", trace.SpanKindInternal)
+	require.NoError(t, err)
+	span.End()
+	outer.AssertSpan(t, "after inner")
+}